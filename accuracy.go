@@ -0,0 +1,137 @@
+//nolint:gomnd
+package placekey
+
+import (
+	"errors"
+	"math"
+	"sort"
+)
+
+const cellArea float64 = 15047.5 // m², average resolution 10 hexagon
+
+var ErrInvalidRadius = errors.New("invalid radius")
+
+// PlaceKeyProbability is a PlaceKey together with the estimated probability
+// that a location lies within it.
+type PlaceKeyProbability struct {
+	PlaceKey    string
+	Probability float64
+}
+
+// Contains returns whether or not a (latitude, longitude) falls in a PlaceKey,
+// using the same cell assignment as FromGeo. The what part of the PlaceKey is
+// ignored.
+func (c *H3) Contains(placeKey string, lat, lng float64) (bool, error) {
+	if !FormatIsValid(placeKey) {
+		return false, ErrInvalidFormat
+	}
+	x, err := ToH3Index(placeKey)
+	if err != nil {
+		return false, err
+	}
+	if lat < -90 || lat > 90 || lng < -180 || lng > 180 {
+		return false, ErrInvalidLatLngRange
+	}
+	return c.h3.FromGeo(GeoCoord{Latitude: lat, Longitude: lng}, resolution) == x, nil
+}
+
+// FromGeoWithAccuracy returns every PlaceKey a location could fall in, given a
+// (latitude, longitude) known within a radius in meters, such as the accuracy
+// of a GPS fix or of a coordinate rounded to a few decimal places.
+//
+// The true location is assumed to be uniformly distributed over the disk of
+// the given radius. The probability of each PlaceKey is the fraction of the
+// disk its cell overlaps, and the result is sorted from the most to the least
+// probable PlaceKey. A radius of zero returns the PlaceKey of the coordinate
+// with probability one. A radius covering too many cells returns
+// ErrCoverTooLarge.
+func (c *H3) FromGeoWithAccuracy(lat, lng, radius float64) ([]PlaceKeyProbability, error) {
+	if radius < 0 || math.IsNaN(radius) || math.IsInf(radius, 0) {
+		return nil, ErrInvalidRadius
+	}
+	center, err := c.FromGeo(lat, lng)
+	if err != nil {
+		return nil, err
+	}
+	if radius == 0 {
+		return []PlaceKeyProbability{{PlaceKey: center, Probability: 1}}, nil
+	}
+	cells, err := c.CoverCircle(lat, lng, radius, ContainmentIntersects)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]PlaceKeyProbability, 0, len(cells))
+	total := 0.0
+	for _, pk := range cells {
+		x, err := ToH3Index(pk)
+		if err != nil {
+			return nil, err
+		}
+		_, _, ring := c.cellGeo(x)
+		overlap := diskOverlap(lat, lng, radius, ring)
+		if overlap > 0 {
+			out = append(out, PlaceKeyProbability{PlaceKey: pk, Probability: overlap})
+			total += overlap
+		}
+	}
+	if total == 0 {
+		return []PlaceKeyProbability{{PlaceKey: center, Probability: 1}}, nil
+	}
+	for i := range out {
+		out[i].Probability /= total
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Probability != out[j].Probability {
+			return out[i].Probability > out[j].Probability
+		}
+		return out[i].PlaceKey < out[j].PlaceKey
+	})
+	return out, nil
+}
+
+// diskOverlap returns the area in m² shared by a cell boundary and the disk of
+// a radius in meters centered on a (latitude, longitude), both projected on
+// the azimuthal equidistant plane centered on the disk.
+func diskOverlap(lat, lng, radius float64, ring [][]float64) float64 {
+	pts := make([]point, 0, len(ring))
+	for _, v := range ring {
+		pts = append(pts, azimuthalProjection(lat, lng, v[0], v[1]))
+	}
+	area := 0.0
+	for i := range pts {
+		area += diskTriangleArea(pts[i], pts[(i+1)%len(pts)], radius)
+	}
+	return math.Abs(area)
+}
+
+// diskTriangleArea returns the signed area shared by the triangle (origin, a,
+// b) and the disk of a radius centered on the origin.
+func diskTriangleArea(a, b point, radius float64) float64 {
+	// split a-b where it crosses the circle, so that each piece lies either
+	// inside the disk, a triangle, or outside of it, a circular sector
+	pieces := []point{a}
+	dx, dy := b.x-a.x, b.y-a.y
+	qa := dx*dx + dy*dy
+	qb := 2 * (a.x*dx + a.y*dy)
+	qc := a.x*a.x + a.y*a.y - radius*radius
+	if disc := qb*qb - 4*qa*qc; qa > 0 && disc > 0 {
+		sq := math.Sqrt(disc)
+		for _, t := range []float64{(-qb - sq) / (2 * qa), (-qb + sq) / (2 * qa)} {
+			if t > 0 && t < 1 {
+				pieces = append(pieces, point{x: a.x + t*dx, y: a.y + t*dy})
+			}
+		}
+	}
+	pieces = append(pieces, b)
+	o := point{}
+	area := 0.0
+	for i := 1; i < len(pieces); i++ {
+		p, q := pieces[i-1], pieces[i]
+		if math.Hypot((p.x+q.x)/2, (p.y+q.y)/2) <= radius {
+			area += cross(o, p, q) / 2
+		} else {
+			area += radius * radius * math.Atan2(cross(o, p, q), p.x*q.x+p.y*q.y) / 2
+		}
+	}
+	return area
+}
@@ -0,0 +1,186 @@
+package placekey
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestH3_Contains(t *testing.T) {
+	tests := []struct {
+		name     string
+		placeKey string
+		lat      float64
+		lng      float64
+		want     bool
+		wantErr  error
+	}{
+		{
+			name:     "SF City Hall",
+			placeKey: "@5vg-7gq-tvz",
+			lat:      37.779274,
+			lng:      -122.419262,
+			want:     true,
+		},
+		{
+			name:     "what part is ignored",
+			placeKey: "zzw-22y@5vg-7gq-tvz",
+			lat:      37.779274,
+			lng:      -122.419262,
+			want:     true,
+		},
+		{
+			name:     "Ferry Building",
+			placeKey: "@5vg-7gq-tvz",
+			lat:      37.795424,
+			lng:      -122.393715,
+			want:     false,
+		},
+		{
+			name:     "malformed",
+			placeKey: "garbage",
+			lat:      37.779274,
+			lng:      -122.419262,
+			wantErr:  ErrInvalidFormat,
+		},
+		{
+			name:     "invalid latitude",
+			placeKey: "@5vg-7gq-tvz",
+			lat:      91,
+			lng:      -122.419262,
+			wantErr:  ErrInvalidLatLngRange,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewH3()
+			defer c.Close()
+			got, err := c.Contains(tt.placeKey, tt.lat, tt.lng)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Contains() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Contains() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestH3_FromGeoWithAccuracy(t *testing.T) {
+	tests := []struct {
+		name      string
+		radius    float64
+		wantMin   int
+		wantMax   int
+		wantFirst string
+	}{
+		{
+			name:      "exact",
+			radius:    0,
+			wantMin:   1,
+			wantMax:   1,
+			wantFirst: "@5vg-7gq-tvz",
+		},
+		{
+			name:    "4 decimal places",
+			radius:  11.132,
+			wantMin: 1,
+			wantMax: 3,
+		},
+		{
+			name:    "3 decimal places",
+			radius:  111.32,
+			wantMin: 3,
+			wantMax: 12,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewH3()
+			defer c.Close()
+			got, err := c.FromGeoWithAccuracy(37.779274, -122.419262, tt.radius)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) < tt.wantMin || len(got) > tt.wantMax {
+				t.Errorf("FromGeoWithAccuracy() got %d placekeys, want between %d and %d", len(got), tt.wantMin, tt.wantMax)
+			}
+			if tt.wantFirst != "" && got[0].PlaceKey != tt.wantFirst {
+				t.Errorf("FromGeoWithAccuracy() got first = %v, want %v", got[0].PlaceKey, tt.wantFirst)
+			}
+			sum := 0.0
+			for i, p := range got {
+				if i > 0 && p.Probability > got[i-1].Probability {
+					t.Errorf("FromGeoWithAccuracy() not sorted at %d", i)
+				}
+				sum += p.Probability
+			}
+			if !almostEqual(sum, 1) {
+				t.Errorf("FromGeoWithAccuracy() probabilities sum to %v", sum)
+			}
+		})
+	}
+}
+
+func TestH3_FromGeoWithAccuracyErrors(t *testing.T) {
+	c := NewH3()
+	defer c.Close()
+	if _, err := c.FromGeoWithAccuracy(0, 0, -1); !errors.Is(err, ErrInvalidRadius) {
+		t.Errorf("FromGeoWithAccuracy() error = %v, wantErr %v", err, ErrInvalidRadius)
+	}
+	if _, err := c.FromGeoWithAccuracy(91, 0, 10); !errors.Is(err, ErrInvalidLatLngRange) {
+		t.Errorf("FromGeoWithAccuracy() error = %v, wantErr %v", err, ErrInvalidLatLngRange)
+	}
+	if _, err := c.FromGeoWithAccuracy(0, 0, 1e6); !errors.Is(err, ErrCoverTooLarge) {
+		t.Errorf("FromGeoWithAccuracy() error = %v, wantErr %v", err, ErrCoverTooLarge)
+	}
+}
+
+func TestH3_FromGeoWithAccuracyLargeRadius(t *testing.T) {
+	c := NewH3()
+	defer c.Close()
+	lat, lng, radius := 37.779274, -122.419262, 2000.0
+	got, err := c.FromGeoWithAccuracy(lat, lng, radius)
+	if err != nil {
+		t.Fatal(err)
+	}
+	probabilities := map[string]float64{}
+	for _, p := range got {
+		probabilities[p.PlaceKey] = p.Probability
+	}
+	full, err := c.CoverCircle(lat, lng, radius, ContainmentFull)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// every cell inside the disk is present, with the share of its own area
+	area := 0.0
+	for _, pk := range full {
+		x, err := ToH3Index(pk)
+		if err != nil {
+			t.Fatal(err)
+		}
+		area += c.h3.CellAreaM2(x)
+		if probabilities[pk] == 0 {
+			t.Errorf("FromGeoWithAccuracy() is missing %s", pk)
+		}
+	}
+	disk := math.Pi * radius * radius
+	if share := area / disk; math.Abs(share-sumProbabilities(full, probabilities))/share > 0.01 {
+		t.Errorf("FromGeoWithAccuracy() got %v for cells covering %v of the disk", sumProbabilities(full, probabilities), share)
+	}
+	intersects, err := c.CoverCircle(lat, lng, radius, ContainmentIntersects)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) < len(full) || len(got) > len(intersects) {
+		t.Errorf("FromGeoWithAccuracy() got %d placekeys, want between %d and %d", len(got), len(full), len(intersects))
+	}
+}
+
+func sumProbabilities(placeKeys []string, probabilities map[string]float64) float64 {
+	sum := 0.0
+	for _, pk := range placeKeys {
+		sum += probabilities[pk]
+	}
+	return sum
+}
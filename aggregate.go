@@ -0,0 +1,151 @@
+package placekey
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+
+	"github.com/diegosz/placekey-go/internal/h3"
+)
+
+var ErrInvalidPrefixLength = errors.New("invalid prefix length")
+
+// Aggregate is the number of values added to a cell and their sum.
+type Aggregate struct {
+	Count int64
+	Sum   float64
+}
+
+// Mean returns the mean of the values, zero when there are none.
+func (a Aggregate) Mean() float64 {
+	if a.Count == 0 {
+		return 0
+	}
+	return a.Sum / float64(a.Count)
+}
+
+func (a Aggregate) add(b Aggregate) Aggregate {
+	return Aggregate{Count: a.Count + b.Count, Sum: a.Sum + b.Sum}
+}
+
+// Aggregator accumulates values by PlaceKey and rolls them up to coarser
+// levels. The what part of the PlaceKeys is dropped.
+//
+// Partial aggregates computed by different workers, each with its own
+// Aggregator, are combined with Merge. An Aggregator is safe for concurrent
+// use.
+type Aggregator struct {
+	mu    sync.RWMutex
+	cells map[h3.Index]Aggregate
+}
+
+// NewAggregator returns an empty Aggregator.
+func NewAggregator() *Aggregator {
+	return &Aggregator{cells: map[h3.Index]Aggregate{}}
+}
+
+// Add counts a value in a PlaceKey.
+func (a *Aggregator) Add(placeKey string, value float64) error {
+	return a.AddAggregate(placeKey, Aggregate{Count: 1, Sum: value})
+}
+
+// AddAggregate adds an aggregate computed elsewhere to a PlaceKey.
+func (a *Aggregator) AddAggregate(placeKey string, agg Aggregate) error {
+	if !FormatIsValid(placeKey) {
+		return ErrInvalidFormat
+	}
+	x, err := ToH3Index(placeKey)
+	if err != nil {
+		return err
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.cells[x] = a.cells[x].add(agg)
+	return nil
+}
+
+// Merge adds the aggregates of another Aggregator.
+func (a *Aggregator) Merge(other *Aggregator) {
+	cells := other.snapshot()
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for x, agg := range cells {
+		a.cells[x] = a.cells[x].add(agg)
+	}
+}
+
+// Len returns the number of PlaceKeys with aggregates.
+func (a *Aggregator) Len() int {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return len(a.cells)
+}
+
+// Get returns the aggregate of a PlaceKey.
+func (a *Aggregator) Get(placeKey string) (Aggregate, error) {
+	x, err := ToH3Index(placeKey)
+	if err != nil {
+		return Aggregate{}, err
+	}
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.cells[x], nil
+}
+
+// PlaceKeys returns the aggregates by PlaceKey.
+func (a *Aggregator) PlaceKeys() map[string]Aggregate {
+	out := map[string]Aggregate{}
+	for x, agg := range a.snapshot() {
+		out[encodeH3Int(uint64(x))] = agg
+	}
+	return out
+}
+
+// Rollup returns the aggregates by parent H3 cell at a resolution from 0 to
+// 10, keyed by H3 string.
+func (a *Aggregator) Rollup(c *H3, res int) (map[string]Aggregate, error) {
+	if res < 0 || res > resolution {
+		return nil, ErrInvalidResolution
+	}
+	parents := map[h3.Index]Aggregate{}
+	for x, agg := range a.snapshot() {
+		p := c.h3.ToParent(x, res)
+		parents[p] = parents[p].add(agg)
+	}
+	out := make(map[string]Aggregate, len(parents))
+	for p, agg := range parents {
+		out[strconv.FormatUint(uint64(p), 16)] = agg
+	}
+	return out, nil
+}
+
+// RollupPrefix returns the aggregates by PlaceKey prefix of a length from 1 to
+// 9 characters, the levels of GetPrefixDistanceMap. Prefixes are written as
+// the beginning of the PlaceKeys, like "@5vg-7g" for a length of 5.
+func (a *Aggregator) RollupPrefix(length int) (map[string]Aggregate, error) {
+	if length < 1 || length > whereCodeLength {
+		return nil, ErrInvalidPrefixLength
+	}
+	out := map[string]Aggregate{}
+	for x, agg := range a.snapshot() {
+		p := placeKeyPrefix(encodeH3Int(uint64(x)), length)
+		out[p] = out[p].add(agg)
+	}
+	return out, nil
+}
+
+func (a *Aggregator) snapshot() map[h3.Index]Aggregate {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	cells := make(map[h3.Index]Aggregate, len(a.cells))
+	for x, agg := range a.cells {
+		cells[x] = agg
+	}
+	return cells
+}
+
+// placeKeyPrefix returns the first characters of the where part of an encoded
+// PlaceKey, with its "@" and dashes.
+func placeKeyPrefix(placeKey string, length int) string {
+	return placeKey[:1+length+(length-1)/tupleLength]
+}
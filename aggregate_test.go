@@ -0,0 +1,157 @@
+package placekey
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestAggregator(t *testing.T) {
+	a := NewAggregator()
+	for _, v := range []struct {
+		placeKey string
+		value    float64
+	}{
+		{"@5vg-7gq-tvz", 1},
+		{"zzw-222@5vg-7gq-tvz", 3},
+		{"@5vg-7gt-qzz", 5},
+	} {
+		if err := a.Add(v.placeKey, v.value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := a.AddAggregate("@5vg-7gt-qzz", Aggregate{Count: 2, Sum: 4}); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Add("invalid", 1); !errors.Is(err, ErrInvalidFormat) {
+		t.Errorf("Add() error = %v", err)
+	}
+	if a.Len() != 2 {
+		t.Errorf("Len() got = %v", a.Len())
+	}
+	got, err := a.Get("@5vg-7gq-tvz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != (Aggregate{Count: 2, Sum: 4}) || got.Mean() != 2 {
+		t.Errorf("Get() got = %+v", got)
+	}
+	want := map[string]Aggregate{
+		"@5vg-7gq-tvz": {Count: 2, Sum: 4},
+		"@5vg-7gt-qzz": {Count: 3, Sum: 9},
+	}
+	if got := a.PlaceKeys(); !reflect.DeepEqual(got, want) {
+		t.Errorf("PlaceKeys() got = %v, want %v", got, want)
+	}
+	if (Aggregate{}).Mean() != 0 {
+		t.Error("Mean() of an empty aggregate is not zero")
+	}
+}
+
+func TestAggregator_Rollup(t *testing.T) {
+	c := NewH3()
+	defer c.Close()
+	a := NewAggregator()
+	placeKeys := testPlaceKeys(t, c, 1000)
+	for i, pk := range placeKeys {
+		if err := a.Add(pk, float64(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	total := Aggregate{Count: 1000, Sum: 999 * 1000 / 2}
+	previous := 1
+	for res := 0; res <= 10; res++ {
+		got, err := a.Rollup(c, res)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sum := Aggregate{}
+		for _, agg := range got {
+			sum = sum.add(agg)
+		}
+		if sum != total || len(got) < previous {
+			t.Errorf("Rollup(%d) got %d cells, total %+v", res, len(got), sum)
+		}
+		previous = len(got)
+	}
+	cells, err := a.Rollup(c, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for pk, agg := range a.PlaceKeys() {
+		h, err := ToH3String(pk)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cells[h] != agg {
+			t.Errorf("Rollup(10) got %+v for %s, want %+v", cells[h], h, agg)
+		}
+	}
+	if _, err := a.Rollup(c, 11); !errors.Is(err, ErrInvalidResolution) {
+		t.Errorf("Rollup() error = %v", err)
+	}
+}
+
+func TestAggregator_RollupPrefix(t *testing.T) {
+	a := NewAggregator()
+	for _, pk := range []string{"@5vg-7gq-tvz", "@5vg-7gq-tvz", "@5vg-7gt-qzz"} {
+		if err := a.Add(pk, 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tests := []struct {
+		length int
+		want   map[string]Aggregate
+	}{
+		{3, map[string]Aggregate{"@5vg": {Count: 3, Sum: 3}}},
+		{5, map[string]Aggregate{"@5vg-7g": {Count: 3, Sum: 3}}},
+		{6, map[string]Aggregate{"@5vg-7gq": {Count: 2, Sum: 2}, "@5vg-7gt": {Count: 1, Sum: 1}}},
+		{9, map[string]Aggregate{"@5vg-7gq-tvz": {Count: 2, Sum: 2}, "@5vg-7gt-qzz": {Count: 1, Sum: 1}}},
+	}
+	for _, tt := range tests {
+		got, err := a.RollupPrefix(tt.length)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("RollupPrefix(%d) got = %v, want %v", tt.length, got, tt.want)
+		}
+	}
+	for _, length := range []int{0, 10} {
+		if _, err := a.RollupPrefix(length); !errors.Is(err, ErrInvalidPrefixLength) {
+			t.Errorf("RollupPrefix(%d) error = %v", length, err)
+		}
+	}
+}
+
+func TestAggregator_Merge(t *testing.T) {
+	c := NewH3()
+	defer c.Close()
+	placeKeys := testPlaceKeys(t, c, 500)
+	whole := NewAggregator()
+	for i, pk := range placeKeys {
+		if err := whole.Add(pk, float64(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	merged := NewAggregator()
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			part := NewAggregator()
+			for i := w; i < len(placeKeys); i += 4 {
+				if err := part.Add(placeKeys[i], float64(i)); err != nil {
+					t.Error(err)
+				}
+			}
+			merged.Merge(part)
+		}(w)
+	}
+	wg.Wait()
+	if !reflect.DeepEqual(merged.PlaceKeys(), whole.PlaceKeys()) {
+		t.Error("Merge() got different aggregates")
+	}
+}
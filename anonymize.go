@@ -0,0 +1,244 @@
+package placekey
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+
+	"github.com/diegosz/placekey-go/internal/h3"
+)
+
+var ErrInvalidCount = errors.New("invalid count")
+
+// Region is a published group of cells of the same resolution holding at
+// least k counts.
+type Region struct {
+	// ID is the H3 string of the first of the cells.
+	ID         string
+	Resolution int
+	// Cells are the H3 strings of the cells of the region.
+	Cells []string
+	// PlaceKeys are the original PlaceKeys merged into the region.
+	PlaceKeys []string
+	Count     int64
+	// Geometry is the outline of the cells, less the regions published at
+	// finer resolutions inside them.
+	Geometry MultiPolygon
+}
+
+// Anonymization is the result of Anonymize.
+type Anonymization struct {
+	Regions []Region
+	// Mapping maps the original PlaceKeys to the ID of their region.
+	Mapping map[string]string
+	// Suppressed are the PlaceKeys that could not reach k counts even merged
+	// into a resolution 0 cell, and are not published.
+	Suppressed []string
+}
+
+// anonymizationGroup is a group of counts pending publication.
+type anonymizationGroup struct {
+	count     int64
+	placeKeys []string
+}
+
+// Anonymize coarsens per-PlaceKey counts until every published region holds
+// at least k counts. The what part of the PlaceKeys is dropped.
+//
+// Starting at resolution 10, cells holding k counts or more are published as
+// is. The remaining cells are grouped with adjacent remaining cells until the
+// group reaches k, cells left over next to a region published at the same
+// resolution join it, and the others are merged into their parent at the next
+// coarser resolution, down to resolution 0. A region published at a
+// coarse resolution may contain regions published at finer ones, which its
+// geometry leaves out. As cells do not nest exactly in their parent, the
+// geometries of adjacent regions of different resolutions may overlap by a
+// sliver along their edges.
+func (c *H3) Anonymize(counts map[string]int64, k int64) (Anonymization, error) {
+	if k < 1 {
+		return Anonymization{}, ErrInvalidK
+	}
+	pending := map[h3.Index]*anonymizationGroup{}
+	for pk, n := range counts {
+		if n < 0 {
+			return Anonymization{}, ErrInvalidCount
+		}
+		if !FormatIsValid(pk) {
+			return Anonymization{}, ErrInvalidFormat
+		}
+		if n == 0 {
+			continue
+		}
+		x, err := ToH3Index(pk)
+		if err != nil {
+			return Anonymization{}, err
+		}
+		g := pending[x]
+		if g == nil {
+			g = &anonymizationGroup{}
+			pending[x] = g
+		}
+		g.count += n
+		g.placeKeys = append(g.placeKeys, pk)
+	}
+
+	type region struct {
+		res   int
+		cells []h3.Index
+		anonymizationGroup
+	}
+	regions := []*region{}
+	for res := resolution; res >= 0; res-- {
+		cells := make([]h3.Index, 0, len(pending))
+		for x := range pending {
+			cells = append(cells, x)
+		}
+		sort.Slice(cells, func(i, j int) bool { return cells[i] < cells[j] })
+		published := map[h3.Index]*region{}
+		publish := func(r *region, x h3.Index) {
+			r.cells = append(r.cells, x)
+			r.count += pending[x].count
+			r.placeKeys = append(r.placeKeys, pending[x].placeKeys...)
+			published[x] = r
+			delete(pending, x)
+		}
+		for _, x := range cells {
+			if pending[x].count >= k {
+				r := &region{res: res}
+				publish(r, x)
+				regions = append(regions, r)
+			}
+		}
+		visited := map[h3.Index]bool{}
+		for _, x := range cells {
+			if pending[x] == nil || visited[x] {
+				continue
+			}
+			if group := c.anonymizationNeighbors(x, pending, visited, k); group != nil {
+				r := &region{res: res}
+				for _, y := range group {
+					publish(r, y)
+				}
+				regions = append(regions, r)
+			}
+		}
+		// cells left over join an adjacent region published at this resolution
+		for _, x := range cells {
+			if pending[x] == nil {
+				continue
+			}
+			for _, n := range c.h3.KRing(x, 1) {
+				if r := published[n]; r != nil {
+					publish(r, x)
+					break
+				}
+			}
+		}
+		if res == 0 {
+			break
+		}
+		parents := map[h3.Index]*anonymizationGroup{}
+		for x, g := range pending {
+			p := c.h3.ToParent(x, res-1)
+			if parents[p] == nil {
+				parents[p] = &anonymizationGroup{}
+			}
+			parents[p].count += g.count
+			parents[p].placeKeys = append(parents[p].placeKeys, g.placeKeys...)
+		}
+		pending = parents
+	}
+
+	// the published cells, and the ancestors of those nested in coarser
+	// regions
+	published := map[h3.Index]bool{}
+	nested := map[h3.Index]bool{}
+	for _, r := range regions {
+		for _, x := range r.cells {
+			published[x] = true
+			for res := r.res - 1; res >= 0; res-- {
+				nested[c.h3.ToParent(x, res)] = true
+			}
+		}
+	}
+
+	out := Anonymization{Regions: []Region{}, Mapping: map[string]string{}, Suppressed: []string{}}
+	for _, r := range regions {
+		sort.Slice(r.cells, func(i, j int) bool { return r.cells[i] < r.cells[j] })
+		sort.Strings(r.placeKeys)
+		region := Region{
+			Resolution: r.res,
+			Cells:      make([]string, 0, len(r.cells)),
+			PlaceKeys:  r.placeKeys,
+			Count:      r.count,
+			Geometry:   c.regionGeometry(r.cells, r.res, published, nested),
+		}
+		for _, x := range r.cells {
+			region.Cells = append(region.Cells, strconv.FormatUint(uint64(x), 16))
+		}
+		region.ID = region.Cells[0]
+		for _, pk := range region.PlaceKeys {
+			out.Mapping[pk] = region.ID
+		}
+		out.Regions = append(out.Regions, region)
+	}
+	for _, g := range pending {
+		out.Suppressed = append(out.Suppressed, g.placeKeys...)
+	}
+	sort.Strings(out.Suppressed)
+	sort.Slice(out.Regions, func(i, j int) bool { return out.Regions[i].ID < out.Regions[j].ID })
+	return out, nil
+}
+
+// regionGeometry returns the outline of the cells of a region at a resolution,
+// less the finer regions inside them: a cell holding finer regions is replaced
+// by its children that are not published, recursively, and the cells of each
+// resolution are outlined apart.
+func (c *H3) regionGeometry(cells []h3.Index, res int, published, nested map[h3.Index]bool) MultiPolygon {
+	leftover := make([][]h3.Index, resolution+1)
+	var add func(x h3.Index, res int)
+	add = func(x h3.Index, res int) {
+		if !nested[x] {
+			leftover[res] = append(leftover[res], x)
+			return
+		}
+		for _, child := range c.h3.ToChildren(x, res+1) {
+			if !published[child] {
+				add(child, res+1)
+			}
+		}
+	}
+	for _, x := range cells {
+		add(x, res)
+	}
+	geometry := MultiPolygon{}
+	for _, xs := range leftover {
+		if len(xs) > 0 {
+			geometry = append(geometry, c.h3.SetToMultiPolygon(xs)...)
+		}
+	}
+	return geometry
+}
+
+// anonymizationNeighbors grows a group of adjacent pending cells from x,
+// breadth first, until it holds k counts. It returns the group when it reaches
+// k, or nil when the adjacent pending cells hold less than k counts.
+func (c *H3) anonymizationNeighbors(x h3.Index, pending map[h3.Index]*anonymizationGroup, visited map[h3.Index]bool, k int64) []h3.Index {
+	visited[x] = true
+	group := []h3.Index{x}
+	count := pending[x].count
+	for i := 0; i < len(group) && count < k; i++ {
+		for _, n := range c.h3.KRing(group[i], 1) {
+			if pending[n] == nil || visited[n] || count >= k {
+				continue
+			}
+			visited[n] = true
+			group = append(group, n)
+			count += pending[n].count
+		}
+	}
+	if count < k {
+		return nil
+	}
+	return group
+}
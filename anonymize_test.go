@@ -0,0 +1,146 @@
+package placekey
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/diegosz/placekey-go/internal/h3"
+)
+
+func TestH3_Anonymize(t *testing.T) {
+	c := NewH3()
+	defer c.Close()
+	counts := map[string]int64{}
+	total := int64(0)
+	for i, pk := range testPlaceKeys(t, c, 500) {
+		n := int64(i%7) + 1
+		if i%50 == 0 {
+			n = 40
+		}
+		counts[pk] += n
+		total += n
+	}
+	if _, ok := counts["@5vg-7gq-tvz"]; ok {
+		t.Fatal("test PlaceKeys contain @5vg-7gq-tvz")
+	}
+	counts["@5vg-7gq-tvz"] = 0
+	// a lone cell far from the others
+	counts["@nxd-g5g-xyv"] = 1
+	total++
+
+	got, err := c.Anonymize(counts, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	published := int64(0)
+	resolutions := map[int]bool{}
+	for _, r := range got.Regions {
+		resolutions[r.Resolution] = true
+		if r.Count < 20 {
+			t.Errorf("Anonymize() region %s has %d counts", r.ID, r.Count)
+		}
+		if len(r.Geometry) == 0 {
+			t.Errorf("Anonymize() region %s has no geometry", r.ID)
+		}
+		sum := int64(0)
+		for _, pk := range r.PlaceKeys {
+			sum += counts[pk]
+			if got.Mapping[pk] != r.ID {
+				t.Errorf("Anonymize() mapping of %s got = %v, want %v", pk, got.Mapping[pk], r.ID)
+			}
+			x, err := ToH3Index(pk)
+			if err != nil {
+				t.Fatal(err)
+			}
+			parent := strconv.FormatUint(uint64(c.h3.ToParent(x, r.Resolution)), 16)
+			if !containsString(r.Cells, parent) {
+				t.Errorf("Anonymize() region %s does not contain %s", r.ID, pk)
+			}
+		}
+		if sum != r.Count {
+			t.Errorf("Anonymize() region %s got count %d, want %d", r.ID, r.Count, sum)
+		}
+		published += sum
+	}
+	if len(resolutions) < 2 {
+		t.Errorf("Anonymize() published regions at resolutions %v", resolutions)
+	}
+
+	// the geometries do not overlap at the centers of the cells of the regions
+	// and of the PlaceKeys, coarse regions leaving out the finer ones inside
+	centers := []h3.Index{}
+	nested := 0
+	for _, r := range got.Regions {
+		for _, cell := range r.Cells {
+			x, err := strconv.ParseUint(cell, 16, 64)
+			if err != nil {
+				t.Fatal(err)
+			}
+			centers = append(centers, h3.Index(x))
+			for _, o := range got.Regions {
+				parent := strconv.FormatUint(uint64(c.h3.ToParent(h3.Index(x), o.Resolution)), 16)
+				if o.Resolution < r.Resolution && containsString(o.Cells, parent) {
+					nested++
+				}
+			}
+		}
+		for _, pk := range r.PlaceKeys {
+			x, err := ToH3Index(pk)
+			if err != nil {
+				t.Fatal(err)
+			}
+			centers = append(centers, x)
+		}
+	}
+	if nested == 0 {
+		t.Error("Anonymize() published no region inside a coarser one")
+	}
+	for _, x := range centers {
+		center := c.h3.ToGeo(x)
+		in := []string{}
+		for _, r := range got.Regions {
+			for _, polygon := range r.Geometry {
+				if polygonContains(polygon, center.Latitude, center.Longitude) {
+					in = append(in, r.ID)
+					break
+				}
+			}
+		}
+		if len(in) > 1 {
+			t.Errorf("Anonymize() regions %v overlap at the center of %x", in, uint64(x))
+		}
+	}
+	if _, ok := got.Mapping["@5vg-7gq-tvz"]; ok {
+		t.Error("Anonymize() mapped a zero count")
+	}
+	if len(got.Suppressed) != 1 || got.Suppressed[0] != "@nxd-g5g-xyv" {
+		t.Errorf("Anonymize() suppressed = %v", got.Suppressed)
+	}
+	if published+1 != total || len(got.Mapping) != 500 {
+		t.Errorf("Anonymize() published %d of %d counts, mapped %d PlaceKeys", published, total, len(got.Mapping))
+	}
+
+	// nothing to coarsen
+	got, err = c.Anonymize(map[string]int64{"@5vg-7gq-tvz": 5, "@5vg-7gt-qzz": 6}, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Regions) != 2 || got.Regions[0].Resolution != 10 || got.Regions[1].Resolution != 10 {
+		t.Errorf("Anonymize() got = %+v", got.Regions)
+	}
+
+	for _, tt := range []struct {
+		counts map[string]int64
+		k      int64
+		err    error
+	}{
+		{map[string]int64{"@5vg-7gq-tvz": 1}, 0, ErrInvalidK},
+		{map[string]int64{"@5vg-7gq-tvz": -1}, 1, ErrInvalidCount},
+		{map[string]int64{"invalid": 1}, 1, ErrInvalidFormat},
+	} {
+		if _, err := c.Anonymize(tt.counts, tt.k); !errors.Is(err, tt.err) {
+			t.Errorf("Anonymize() error = %v, want %v", err, tt.err)
+		}
+	}
+}
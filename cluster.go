@@ -0,0 +1,197 @@
+package placekey
+
+import (
+	"errors"
+	"math"
+
+	"github.com/diegosz/placekey-go/internal/h3"
+)
+
+// ClusterDistance selects how the neighborhood of a PlaceKey is measured.
+type ClusterDistance int
+
+const (
+	// ClusterGridDistance measures the grid distance, in cells.
+	ClusterGridDistance ClusterDistance = iota
+	// ClusterMetricDistance measures the distance in meters between the
+	// centers of the PlaceKeys.
+	ClusterMetricDistance
+)
+
+// ClusterLabel is the DBSCAN role of a point.
+type ClusterLabel int
+
+const (
+	// ClusterNoise points belong to no cluster.
+	ClusterNoise ClusterLabel = iota
+	// ClusterCore points have at least MinPts points in their neighborhood.
+	ClusterCore
+	// ClusterBorder points are not core points but are in the neighborhood of
+	// a core point.
+	ClusterBorder
+)
+
+func (l ClusterLabel) String() string {
+	switch l {
+	case ClusterNoise:
+		return "noise"
+	case ClusterCore:
+		return "core"
+	case ClusterBorder:
+		return "border"
+	default:
+		return "unknown"
+	}
+}
+
+var ErrInvalidClusterDistance = errors.New("invalid cluster distance")
+var ErrInvalidEps = errors.New("invalid eps")
+var ErrInvalidMinPts = errors.New("invalid minPts")
+
+// ClusterOptions configures DBSCAN.
+type ClusterOptions struct {
+	Distance ClusterDistance
+	// Eps is the radius of the neighborhood of a point, in grid steps, rounded
+	// down, or in meters.
+	Eps float64
+	// MinPts is the number of points, the point itself included, a
+	// neighborhood must hold for the point to be a core point.
+	MinPts int
+}
+
+// ClusterPoint is the clustering of a point. Cluster is -1 for noise.
+type ClusterPoint struct {
+	PlaceKey string
+	Cluster  int
+	Label    ClusterLabel
+}
+
+// Cluster is a group of points with the outline of their PlaceKeys.
+type Cluster struct {
+	ID        int
+	PlaceKeys []string
+	Outline   MultiPolygon
+}
+
+// Clustering is the result of DBSCAN. Points are in the order of the input.
+type Clustering struct {
+	Points   []ClusterPoint
+	Clusters []Cluster
+}
+
+// DBSCAN clusters PlaceKeys by density. Each PlaceKey is a point, repeated
+// PlaceKeys being distinct points in the same place. Clusters are numbered in
+// the order of their first core point in the input, and border points reachable
+// from several clusters go to the first one.
+func (c *H3) DBSCAN(placeKeys []string, opts ClusterOptions) (Clustering, error) {
+	if opts.Distance < ClusterGridDistance || opts.Distance > ClusterMetricDistance {
+		return Clustering{}, ErrInvalidClusterDistance
+	}
+	if opts.Eps < 0 || math.IsNaN(opts.Eps) || math.IsInf(opts.Eps, 0) {
+		return Clustering{}, ErrInvalidEps
+	}
+	if opts.MinPts < 1 {
+		return Clustering{}, ErrInvalidMinPts
+	}
+	cells := make([]h3.Index, len(placeKeys))
+	points := map[h3.Index][]int{}
+	idx := NewIndex[struct{}]()
+	for i, pk := range placeKeys {
+		x, err := ToH3Index(pk)
+		if err != nil {
+			return Clustering{}, err
+		}
+		cells[i] = x
+		if points[x] == nil && opts.Distance == ClusterMetricDistance {
+			if err := idx.Insert(encodeH3Int(uint64(x)), struct{}{}); err != nil {
+				return Clustering{}, err
+			}
+		}
+		points[x] = append(points[x], i)
+	}
+
+	// neighborhoods are shared by the points of a cell
+	neighborhoods := map[h3.Index][]int{}
+	neighborhood := func(x h3.Index) ([]int, error) {
+		if n, ok := neighborhoods[x]; ok {
+			return n, nil
+		}
+		n := []int{}
+		if opts.Distance == ClusterGridDistance {
+			for _, y := range c.h3.KRing(x, int(opts.Eps)) {
+				n = append(n, points[y]...)
+			}
+		} else {
+			center := c.h3.ToGeo(x)
+			items, err := idx.WithinRadius(c, center.Latitude, center.Longitude, opts.Eps)
+			if err != nil {
+				return nil, err
+			}
+			for _, item := range items {
+				y, err := ToH3Index(item.PlaceKey)
+				if err != nil {
+					return nil, err
+				}
+				n = append(n, points[y]...)
+			}
+		}
+		neighborhoods[x] = n
+		return n, nil
+	}
+
+	out := Clustering{Points: make([]ClusterPoint, len(placeKeys)), Clusters: []Cluster{}}
+	for i, pk := range placeKeys {
+		out.Points[i] = ClusterPoint{PlaceKey: pk, Cluster: -1, Label: ClusterNoise}
+	}
+	visited := make([]bool, len(placeKeys))
+	for i := range placeKeys {
+		if visited[i] {
+			continue
+		}
+		visited[i] = true
+		n, err := neighborhood(cells[i])
+		if err != nil {
+			return Clustering{}, err
+		}
+		if len(n) < opts.MinPts {
+			continue
+		}
+		id := len(out.Clusters)
+		members := []int{i}
+		out.Points[i].Cluster, out.Points[i].Label = id, ClusterCore
+		queue := append([]int(nil), n...)
+		for len(queue) > 0 {
+			j := queue[0]
+			queue = queue[1:]
+			if out.Points[j].Cluster < 0 {
+				out.Points[j].Cluster, out.Points[j].Label = id, ClusterBorder
+				members = append(members, j)
+			}
+			if visited[j] {
+				continue
+			}
+			visited[j] = true
+			nj, err := neighborhood(cells[j])
+			if err != nil {
+				return Clustering{}, err
+			}
+			if len(nj) >= opts.MinPts {
+				out.Points[j].Label = ClusterCore
+				queue = append(queue, nj...)
+			}
+		}
+		cluster := Cluster{ID: id, PlaceKeys: make([]string, 0, len(members))}
+		hs := []h3.Index{}
+		seen := map[h3.Index]bool{}
+		for _, j := range members {
+			cluster.PlaceKeys = append(cluster.PlaceKeys, placeKeys[j])
+			if !seen[cells[j]] {
+				seen[cells[j]] = true
+				hs = append(hs, cells[j])
+			}
+		}
+		cluster.Outline = MultiPolygon(c.h3.SetToMultiPolygon(hs))
+		out.Clusters = append(out.Clusters, cluster)
+	}
+	return out, nil
+}
@@ -0,0 +1,80 @@
+package placekey
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestH3_DBSCAN(t *testing.T) {
+	c := NewH3()
+	defer c.Close()
+	placeKeys := append(testRing(t, c, "@5vg-7gq-tvz", 1), "@5vg-7gq-tvz")
+	// a cell two steps away from City Hall, next to a single cell of its ring
+	x, err := ToH3Index("@5vg-7gq-tvz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	border := encodeH3Int(uint64(c.h3.HexRing(x, 2)[0]))
+	placeKeys = append(placeKeys, border, "@nxd-g5g-xyv")
+	placeKeys = append(placeKeys, testRing(t, c, "@5vg-7gt-qzz", 1)...)
+
+	for _, opts := range []ClusterOptions{
+		{Distance: ClusterGridDistance, Eps: 1, MinPts: 4},
+		{Distance: ClusterMetricDistance, Eps: 150, MinPts: 4},
+	} {
+		got, err := c.DBSCAN(placeKeys, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got.Clusters) != 2 || len(got.Points) != len(placeKeys) {
+			t.Fatalf("DBSCAN(%+v) got %d clusters, %d points", opts, len(got.Clusters), len(got.Points))
+		}
+		if n := len(got.Clusters[0].PlaceKeys); n != 9 {
+			t.Errorf("DBSCAN(%+v) first cluster got %d points", opts, n)
+		}
+		if n := len(got.Clusters[1].PlaceKeys); n != 7 {
+			t.Errorf("DBSCAN(%+v) second cluster got %d points", opts, n)
+		}
+		for i, p := range got.Points {
+			want := ClusterPoint{PlaceKey: placeKeys[i], Cluster: 0, Label: ClusterCore}
+			switch {
+			case p.PlaceKey == border:
+				want.Label = ClusterBorder
+			case p.PlaceKey == "@nxd-g5g-xyv":
+				want.Cluster, want.Label = -1, ClusterNoise
+			case i > 9:
+				want.Cluster = 1
+			}
+			if p != want {
+				t.Errorf("DBSCAN(%+v) point %d got = %+v, want %+v", opts, i, p, want)
+			}
+		}
+		for _, cl := range got.Clusters {
+			if len(cl.Outline) != 1 || len(cl.Outline[0].Holes) != 0 {
+				t.Errorf("DBSCAN(%+v) cluster %d got outline %v", opts, cl.ID, cl.Outline.WKT())
+			}
+		}
+	}
+
+	// with a large minPts everything is noise
+	got, err := c.DBSCAN(placeKeys, ClusterOptions{Eps: 1, MinPts: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Clusters) != 0 || got.Points[0].Label != ClusterNoise || got.Points[0].Label.String() != "noise" {
+		t.Errorf("DBSCAN() got = %+v", got)
+	}
+
+	for _, tt := range []struct {
+		opts ClusterOptions
+		err  error
+	}{
+		{ClusterOptions{Distance: 2, MinPts: 1}, ErrInvalidClusterDistance},
+		{ClusterOptions{Eps: -1, MinPts: 1}, ErrInvalidEps},
+		{ClusterOptions{Eps: 1}, ErrInvalidMinPts},
+	} {
+		if _, err := c.DBSCAN(placeKeys, tt.opts); !errors.Is(err, tt.err) {
+			t.Errorf("DBSCAN(%+v) error = %v, want %v", tt.opts, err, tt.err)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/diegosz/placekey-go"
+)
+
+// runJoin joins two CSV or NDJSON datasets and writes the matches as NDJSON.
+func runJoin(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("join", flag.ContinueOnError)
+	left := fs.String("left", "", "streamed dataset, CSV or NDJSON (.ndjson, .jsonl)")
+	right := fs.String("right", "", "indexed dataset, CSV or NDJSON (.ndjson, .jsonl)")
+	mode := fs.String("mode", "exact", "match mode: exact, neighbors or distance")
+	k := fs.Int("k", 1, "grid steps of the neighbors mode")
+	meters := fs.Float64("meters", 100, "distance threshold of the distance mode")
+	lat := fs.String("lat", placekey.DefaultRecordFields.Lat, "latitude field")
+	lng := fs.String("lng", placekey.DefaultRecordFields.Lng, "longitude field")
+	pk := fs.String("placekey", placekey.DefaultRecordFields.PlaceKey, "placekey field, used when there are no coordinates")
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+	if *left == "" || *right == "" {
+		fs.Usage()
+		return errUsage
+	}
+	opts := placekey.JoinOptions{K: *k, Meters: *meters}
+	switch *mode {
+	case "exact":
+		opts.Mode = placekey.JoinExact
+	case "neighbors":
+		opts.Mode = placekey.JoinNeighbors
+	case "distance":
+		opts.Mode = placekey.JoinDistance
+	default:
+		return fmt.Errorf("unknown mode %q", *mode)
+	}
+	fields := placekey.RecordFields{Lat: *lat, Lng: *lng, PlaceKey: *pk}
+	lr, lf, err := openRecords(*left, fields)
+	if err != nil {
+		return err
+	}
+	defer lf.Close()
+	rr, rf, err := openRecords(*right, fields)
+	if err != nil {
+		return err
+	}
+	defer rf.Close()
+
+	c := placekey.NewH3()
+	defer c.Close()
+	w := bufio.NewWriter(stdout)
+	enc := json.NewEncoder(w)
+	err = c.Join(lr, rr, opts, func(m placekey.JoinMatch) error {
+		return enc.Encode(struct {
+			Left     map[string]string `json:"left"`
+			Right    map[string]string `json:"right"`
+			Distance float64           `json:"distance"`
+		}{
+			Left:     recordFields(m.Left, *pk),
+			Right:    recordFields(m.Right, *pk),
+			Distance: m.Distance,
+		})
+	})
+	if err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+// openRecords opens a dataset, reading it as NDJSON or CSV according to the
+// file extension.
+func openRecords(name string, fields placekey.RecordFields) (placekey.RecordReader, *os.File, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".ndjson", ".jsonl":
+		return placekey.NewNDJSONRecordReader(f, fields), f, nil
+	default:
+		return placekey.NewCSVRecordReader(f, fields), f, nil
+	}
+}
+
+// recordFields returns the fields of a record with its PlaceKey.
+func recordFields(r placekey.Record, placeKeyField string) map[string]string {
+	fields := make(map[string]string, len(r.Fields)+1)
+	for k, v := range r.Fields {
+		fields[k] = v
+	}
+	fields[placeKeyField] = r.PlaceKey
+	return fields
+}
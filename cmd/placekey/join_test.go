@@ -0,0 +1,44 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunJoin(t *testing.T) {
+	dir := t.TempDir()
+	left := filepath.Join(dir, "points.csv")
+	right := filepath.Join(dir, "pois.ndjson")
+	if err := os.WriteFile(left, []byte("id,lat,lng\na,37.779274,-122.419262\nb,37.795424,-122.393715\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(right, []byte(`{"name":"city hall","placekey":"@5vg-7gq-tvz"}`+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if err := run([]string{"join", "-left", left, "-right", right, "-mode", "distance", "-meters", "10"}, &out); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("join got %d matches, want 1: %s", len(lines), out.String())
+	}
+	var m struct {
+		Left     map[string]string `json:"left"`
+		Right    map[string]string `json:"right"`
+		Distance float64           `json:"distance"`
+	}
+	if err := json.Unmarshal([]byte(lines[0]), &m); err != nil {
+		t.Fatal(err)
+	}
+	if m.Left["id"] != "a" || m.Left["placekey"] != "@5vg-7gq-tvz" || m.Right["name"] != "city hall" || m.Distance != 0 {
+		t.Errorf("join got = %+v", m)
+	}
+	if err := run([]string{"join", "-left", left}, &out); err == nil {
+		t.Error("join expected usage error")
+	}
+}
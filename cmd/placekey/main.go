@@ -0,0 +1,57 @@
+// Command placekey works with datasets located by PlaceKeys.
+//
+// Usage:
+//
+//	placekey <command> [flags]
+//
+// Run "placekey <command> -h" for the flags of a command.
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+var errUsage = errors.New("usage")
+
+// commands maps the command names to their implementation.
+var commands = map[string]func(args []string, stdout io.Writer) error{
+	"join":   runJoin,
+	"render": runRender,
+	"track":  runTrack,
+}
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout); err != nil {
+		if !errors.Is(err, errUsage) {
+			fmt.Fprintln(os.Stderr, "placekey:", err)
+		}
+		os.Exit(1)
+	}
+}
+
+func run(args []string, stdout io.Writer) error {
+	if len(args) == 0 {
+		usage()
+		return errUsage
+	}
+	cmd, ok := commands[args[0]]
+	if !ok {
+		usage()
+		return errUsage
+	}
+	return cmd(args[1:], stdout)
+}
+
+func usage() {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	fmt.Fprintf(os.Stderr, "usage: placekey <command> [flags]\n\ncommands: %s\n", strings.Join(names, ", "))
+}
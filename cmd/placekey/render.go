@@ -0,0 +1,88 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/diegosz/placekey-go"
+)
+
+// runRender draws the PlaceKeys of a CSV or NDJSON dataset as SVG.
+func runRender(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("render", flag.ContinueOnError)
+	in := fs.String("in", "", "dataset, CSV or NDJSON (.ndjson, .jsonl)")
+	out := fs.String("out", "", "SVG file, standard output when empty")
+	value := fs.String("value", "", "numeric field filling the cells, summed by placekey")
+	category := fs.String("category", "", "field filling the cells by category, instead of -value")
+	labels := fs.Bool("labels", false, "write the placekeys on the cells")
+	width := fs.Int("width", 800, "width of the image in pixels")
+	lat := fs.String("lat", placekey.DefaultRecordFields.Lat, "latitude field")
+	lng := fs.String("lng", placekey.DefaultRecordFields.Lng, "longitude field")
+	pk := fs.String("placekey", placekey.DefaultRecordFields.PlaceKey, "placekey field, used when there are no coordinates")
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+	if *in == "" {
+		fs.Usage()
+		return errUsage
+	}
+	rr, f, err := openRecords(*in, placekey.RecordFields{Lat: *lat, Lng: *lng, PlaceKey: *pk})
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	c := placekey.NewH3()
+	defer c.Close()
+	opts := placekey.RenderOptions{Width: *width, Padding: 10, Labels: *labels}
+	if *category != "" {
+		opts.Categories = map[string]string{}
+	} else if *value != "" {
+		opts.Values = map[string]float64{}
+	}
+	placeKeys := []string{}
+	seen := map[string]bool{}
+	for {
+		r, err := rr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if err := c.Locate(&r); err != nil {
+			return err
+		}
+		if !seen[r.PlaceKey] {
+			seen[r.PlaceKey] = true
+			placeKeys = append(placeKeys, r.PlaceKey)
+		}
+		switch {
+		case opts.Categories != nil:
+			opts.Categories[r.PlaceKey] = r.Fields[*category]
+		case opts.Values != nil:
+			v, err := strconv.ParseFloat(r.Fields[*value], 64)
+			if err != nil {
+				return fmt.Errorf("%s of %s: %w", *value, r.PlaceKey, err)
+			}
+			opts.Values[r.PlaceKey] += v
+		}
+	}
+
+	if *out == "" {
+		return c.RenderSVG(stdout, placeKeys, opts)
+	}
+	of, err := os.Create(*out)
+	if err != nil {
+		return err
+	}
+	if err := c.RenderSVG(of, placeKeys, opts); err != nil {
+		of.Close()
+		return err
+	}
+	return of.Close()
+}
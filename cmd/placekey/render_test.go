@@ -0,0 +1,48 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunRender(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "pois.csv")
+	data := "placekey,lat,lng,visits,kind\n" +
+		"@5vg-7gq-tvz,,,3,park\n" +
+		",37.7793,-122.4193,2,museum\n" +
+		"@5vg-7gt-qzz,,,1,park\n"
+	if err := os.WriteFile(in, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if err := run([]string{"render", "-in", in, "-value", "visits", "-labels"}, &out); err != nil {
+		t.Fatal(err)
+	}
+	svg := out.String()
+	if !strings.HasPrefix(svg, "<svg ") || strings.Count(svg, "<polygon ") != 2 || strings.Count(svg, "<text ") != 2 {
+		t.Errorf("render got = %s", svg)
+	}
+	if !strings.Contains(svg, `fill="#bd0026"`) || !strings.Contains(svg, `fill="#ffeda0"`) {
+		t.Errorf("render got no value colors: %s", svg)
+	}
+
+	name := filepath.Join(dir, "pois.svg")
+	out.Reset()
+	if err := run([]string{"render", "-in", in, "-category", "kind", "-out", name}, &out); err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.Len() != 0 || !strings.Contains(string(b), `fill="#4e79a7"`) || strings.Contains(string(b), "<text ") {
+		t.Errorf("render got = %s", b)
+	}
+	if err := run([]string{"render"}, &out); err == nil {
+		t.Error("render expected usage error")
+	}
+}
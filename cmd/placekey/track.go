@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/diegosz/placekey-go"
+)
+
+// runTrack reads a GPX file or an NMEA log and writes its points as CSV.
+func runTrack(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("track", flag.ContinueOnError)
+	in := fs.String("in", "", "GPX file (.gpx) or NMEA log")
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+	if *in == "" {
+		fs.Usage()
+		return errUsage
+	}
+	f, err := os.Open(*in)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	c := placekey.NewH3()
+	defer c.Close()
+	var points []placekey.TrackPoint
+	if strings.ToLower(filepath.Ext(*in)) == ".gpx" {
+		points, err = c.ReadGPX(f)
+	} else {
+		points, err = c.ReadNMEA(f)
+	}
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(stdout)
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"kind", "name", "time", "lat", "lng", "placekey"}); err != nil {
+		return err
+	}
+	for _, p := range points {
+		t := ""
+		if !p.Time.IsZero() {
+			t = p.Time.Format(time.RFC3339Nano)
+		}
+		err := cw.Write([]string{
+			p.Kind,
+			p.Name,
+			t,
+			strconv.FormatFloat(p.Lat, 'f', -1, 64),
+			strconv.FormatFloat(p.Lng, 'f', -1, 64),
+			p.PlaceKey,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return err
+	}
+	return w.Flush()
+}
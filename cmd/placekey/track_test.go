@@ -0,0 +1,32 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRunTrack(t *testing.T) {
+	var out bytes.Buffer
+	if err := run([]string{"track", "-in", "../../test/example.gpx"}, &out); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 8 {
+		t.Fatalf("track got %d lines, want 8: %s", len(lines), out.String())
+	}
+	if lines[0] != "kind,name,time,lat,lng,placekey" || !strings.HasPrefix(lines[1], "wpt,SF City Hall,,") || !strings.HasSuffix(lines[1], ",@5vg-7gq-tvz") {
+		t.Errorf("track got = %s", out.String())
+	}
+	out.Reset()
+	if err := run([]string{"track", "-in", "../../test/example.nmea"}, &out); err != nil {
+		t.Fatal(err)
+	}
+	lines = strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 5 || !strings.HasPrefix(lines[2], "fix,,2022-07-31T23:59:59Z,") {
+		t.Errorf("track got = %s", out.String())
+	}
+	if err := run([]string{"track"}, &out); err == nil {
+		t.Error("track expected usage error")
+	}
+}
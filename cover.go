@@ -0,0 +1,177 @@
+//nolint:gomnd
+package placekey
+
+import (
+	"errors"
+	"math"
+	"sort"
+
+	"github.com/diegosz/placekey-go/internal/h3"
+)
+
+// Containment selects which cells of an area are part of its cover.
+type Containment int
+
+const (
+	// ContainmentCentroid selects the cells whose center is inside the area.
+	ContainmentCentroid Containment = iota
+	// ContainmentFull selects the cells entirely inside the area.
+	ContainmentFull
+	// ContainmentIntersects selects the cells overlapping the area.
+	ContainmentIntersects
+)
+
+const maxCoverCells float64 = 1 << 22
+
+var ErrInvalidContainment = errors.New("invalid containment")
+var ErrInvalidBoundingBox = errors.New("invalid bounding box")
+var ErrCoverTooLarge = errors.New("cover too large")
+
+// CoverCircle returns the PlaceKeys covering the circle of a radius in meters
+// centered on a (latitude, longitude), selected according to the containment
+// mode.
+//
+// Distances are measured on the sphere, so circles crossing the antimeridian
+// or containing a pole are covered correctly.
+func (c *H3) CoverCircle(lat, lng, meters float64, mode Containment) ([]string, error) {
+	if meters < 0 || math.IsNaN(meters) || math.IsInf(meters, 0) {
+		return nil, ErrInvalidRadius
+	}
+	if mode < ContainmentCentroid || mode > ContainmentIntersects {
+		return nil, ErrInvalidContainment
+	}
+	if math.Pi*meters*meters/cellArea > maxCoverCells {
+		return nil, ErrCoverTooLarge
+	}
+	center, err := c.FromGeo(lat, lng)
+	if err != nil {
+		return nil, err
+	}
+	seed, err := ToH3Index(center)
+	if err != nil {
+		return nil, err
+	}
+	return c.cover(seed, func(x h3.Index) (bool, bool) {
+		cLat, cLng, ring := c.cellGeo(x)
+		centroid := geoDistance(lat, lng, cLat, cLng) <= meters
+		full := true
+		for _, v := range ring {
+			full = full && geoDistance(lat, lng, v[0], v[1]) <= meters
+		}
+		intersects := centroid || full || boundaryDistance(lat, lng, ring) <= meters
+		return intersects, containment(mode, centroid, full, intersects)
+	})
+}
+
+// CoverBBox returns the PlaceKeys covering a bounding box in degrees, selected
+// according to the containment mode.
+//
+// A minLng greater than maxLng describes a box crossing the antimeridian. A box
+// reaching a pole covers the cells around it.
+func (c *H3) CoverBBox(minLat, minLng, maxLat, maxLng float64, mode Containment) ([]string, error) {
+	for _, lat := range []float64{minLat, maxLat} {
+		if lat < -90 || lat > 90 {
+			return nil, ErrInvalidLatLngRange
+		}
+	}
+	for _, lng := range []float64{minLng, maxLng} {
+		if lng < -180 || lng > 180 {
+			return nil, ErrInvalidLatLngRange
+		}
+	}
+	if minLat > maxLat {
+		return nil, ErrInvalidBoundingBox
+	}
+	if mode < ContainmentCentroid || mode > ContainmentIntersects {
+		return nil, ErrInvalidContainment
+	}
+	box := BoundingBox{North: maxLat, South: minLat, East: maxLng, West: minLng}
+	east := maxLng
+	if box.CrossesAntimeridian() {
+		east += 360
+	}
+	area := math.Pow(earthRadius*1000, 2) * radians(east-minLng) * (math.Sin(radians(maxLat)) - math.Sin(radians(minLat)))
+	if area/cellArea > maxCoverCells {
+		return nil, ErrCoverTooLarge
+	}
+	midLng := (minLng + east) / 2
+	center, err := c.FromGeo((minLat+maxLat)/2, normalizeLng(midLng))
+	if err != nil {
+		return nil, err
+	}
+	seed, err := ToH3Index(center)
+	if err != nil {
+		return nil, err
+	}
+	rect := []point{{minLng, minLat}, {east, minLat}, {east, maxLat}, {minLng, maxLat}}
+	return c.cover(seed, func(x h3.Index) (bool, bool) {
+		cLat, cLng, ring := c.cellGeo(x)
+		centroid := box.Contains(cLat, cLng)
+		if cellBox := boundingBox(ring); cellBox.East-cellBox.West == 360 {
+			// the cell surrounds a pole, its ring is not a polygon in the
+			// (longitude, latitude) plane
+			intersects := cellBox.South <= maxLat && cellBox.North >= minLat
+			full := east-minLng == 360 && cellBox.South >= minLat && cellBox.North <= maxLat
+			return intersects, containment(mode, centroid, full, intersects)
+		}
+		pts := unwrapRing(ring, midLng)
+		full := true
+		for _, p := range pts {
+			full = full && p.x >= minLng && p.x <= east && p.y >= minLat && p.y <= maxLat
+		}
+		intersects := centroid || full || ringsIntersect(pts, rect)
+		return intersects, containment(mode, centroid, full, intersects)
+	})
+}
+
+// cover floods the grid from a seed cell through every cell intersecting an
+// area, and returns the sorted PlaceKeys of the cells selected by test.
+func (c *H3) cover(seed h3.Index, test func(x h3.Index) (intersects, selected bool)) ([]string, error) {
+	seen := map[h3.Index]bool{seed: true}
+	queue := []h3.Index{seed}
+	out := []string{}
+	for len(queue) > 0 {
+		x := queue[0]
+		queue = queue[1:]
+		intersects, selected := test(x)
+		if !intersects {
+			continue
+		}
+		if selected {
+			out = append(out, encodeH3Int(uint64(x)))
+		}
+		for _, n := range c.h3.KRing(x, 1) {
+			if !seen[n] {
+				seen[n] = true
+				queue = append(queue, n)
+			}
+		}
+		if float64(len(seen)) > maxCoverCells {
+			return nil, ErrCoverTooLarge
+		}
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+// cellGeo returns the center and the boundary of a cell.
+func (c *H3) cellGeo(x h3.Index) (lat, lng float64, ring [][]float64) {
+	center := c.h3.ToGeo(x)
+	boundary := c.h3.ToGeoBoundary(x)
+	ring = make([][]float64, 0, len(boundary))
+	for _, v := range boundary {
+		ring = append(ring, []float64{v.Latitude, v.Longitude})
+	}
+	return center.Latitude, center.Longitude, ring
+}
+
+func containment(mode Containment, centroid, full, intersects bool) bool {
+	switch mode {
+	case ContainmentFull:
+		return full
+	case ContainmentIntersects:
+		return intersects
+	default:
+		return centroid
+	}
+}
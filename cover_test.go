@@ -0,0 +1,180 @@
+package placekey
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestH3_CoverCircle(t *testing.T) {
+	tests := []struct {
+		name   string
+		lat    float64
+		lng    float64
+		meters float64
+	}{
+		{
+			name:   "SF City Hall",
+			lat:    37.779274,
+			lng:    -122.419262,
+			meters: 200,
+		},
+		{
+			name:   "antimeridian",
+			lat:    0,
+			lng:    180,
+			meters: 200,
+		},
+		{
+			name:   "north pole",
+			lat:    90,
+			lng:    0,
+			meters: 300,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewH3()
+			defer c.Close()
+			covers := map[Containment][]string{}
+			for _, mode := range []Containment{ContainmentCentroid, ContainmentFull, ContainmentIntersects} {
+				got, err := c.CoverCircle(tt.lat, tt.lng, tt.meters, mode)
+				if err != nil {
+					t.Fatal(err)
+				}
+				covers[mode] = got
+			}
+			assertCoverModes(t, covers)
+			center, err := c.FromGeo(tt.lat, tt.lng)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !containsString(covers[ContainmentCentroid], center) {
+				t.Errorf("CoverCircle() does not contain center %s", center)
+			}
+			for _, pk := range covers[ContainmentCentroid] {
+				lat, lng, err := c.ToGeo(pk)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if d := geoDistance(tt.lat, tt.lng, lat, lng); d > tt.meters {
+					t.Errorf("CoverCircle() %s centroid at %v meters", pk, d)
+				}
+			}
+		})
+	}
+}
+
+func TestH3_CoverBBox(t *testing.T) {
+	tests := []struct {
+		name   string
+		minLat float64
+		minLng float64
+		maxLat float64
+		maxLng float64
+	}{
+		{
+			name:   "SF",
+			minLat: 37.775,
+			minLng: -122.425,
+			maxLat: 37.785,
+			maxLng: -122.415,
+		},
+		{
+			name:   "antimeridian",
+			minLat: -0.002,
+			minLng: 179.998,
+			maxLat: 0.002,
+			maxLng: -179.998,
+		},
+		{
+			name:   "north pole",
+			minLat: 89.998,
+			minLng: -180,
+			maxLat: 90,
+			maxLng: 180,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewH3()
+			defer c.Close()
+			covers := map[Containment][]string{}
+			for _, mode := range []Containment{ContainmentCentroid, ContainmentFull, ContainmentIntersects} {
+				got, err := c.CoverBBox(tt.minLat, tt.minLng, tt.maxLat, tt.maxLng, mode)
+				if err != nil {
+					t.Fatal(err)
+				}
+				covers[mode] = got
+			}
+			assertCoverModes(t, covers)
+			box := BoundingBox{North: tt.maxLat, South: tt.minLat, East: tt.maxLng, West: tt.minLng}
+			east, west := false, false
+			for _, pk := range covers[ContainmentCentroid] {
+				lat, lng, err := c.ToGeo(pk)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !box.Contains(lat, lng) {
+					t.Errorf("CoverBBox() %s centroid outside box", pk)
+				}
+				east = east || lng > 0
+				west = west || lng < 0
+			}
+			if box.CrossesAntimeridian() && !(east && west) {
+				t.Error("CoverBBox() does not cover both sides of the antimeridian")
+			}
+		})
+	}
+}
+
+func TestH3_CoverErrors(t *testing.T) {
+	c := NewH3()
+	defer c.Close()
+	if _, err := c.CoverCircle(0, 0, -1, ContainmentCentroid); !errors.Is(err, ErrInvalidRadius) {
+		t.Errorf("CoverCircle() error = %v, wantErr %v", err, ErrInvalidRadius)
+	}
+	if _, err := c.CoverCircle(0, 0, 1e6, ContainmentCentroid); !errors.Is(err, ErrCoverTooLarge) {
+		t.Errorf("CoverCircle() error = %v, wantErr %v", err, ErrCoverTooLarge)
+	}
+	if _, err := c.CoverCircle(0, 0, 10, Containment(7)); !errors.Is(err, ErrInvalidContainment) {
+		t.Errorf("CoverCircle() error = %v, wantErr %v", err, ErrInvalidContainment)
+	}
+	if _, err := c.CoverBBox(1, 0, 0, 1, ContainmentCentroid); !errors.Is(err, ErrInvalidBoundingBox) {
+		t.Errorf("CoverBBox() error = %v, wantErr %v", err, ErrInvalidBoundingBox)
+	}
+	if _, err := c.CoverBBox(0, 0, 1, 181, ContainmentCentroid); !errors.Is(err, ErrInvalidLatLngRange) {
+		t.Errorf("CoverBBox() error = %v, wantErr %v", err, ErrInvalidLatLngRange)
+	}
+}
+
+// assertCoverModes checks that fully inside cells are a subset of the cells
+// with their centroid inside, themselves a subset of the intersecting cells.
+func assertCoverModes(t *testing.T, covers map[Containment][]string) {
+	t.Helper()
+	full, centroid, intersects := covers[ContainmentFull], covers[ContainmentCentroid], covers[ContainmentIntersects]
+	if len(centroid) == 0 {
+		t.Fatal("empty centroid cover")
+	}
+	for _, pk := range full {
+		if !containsString(centroid, pk) {
+			t.Errorf("full cover %s not in centroid cover", pk)
+		}
+	}
+	for _, pk := range centroid {
+		if !containsString(intersects, pk) {
+			t.Errorf("centroid cover %s not in intersects cover", pk)
+		}
+	}
+	if len(intersects) <= len(full) {
+		t.Errorf("intersects cover %d not larger than full cover %d", len(intersects), len(full))
+	}
+}
+
+func containsString(ss []string, s string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,277 @@
+package placekey
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/diegosz/placekey-go/internal/h3"
+)
+
+// Flow is the aggregate of the trips from an origin to a destination, with
+// the centroids of both ends.
+type Flow struct {
+	Origin, Destination string
+	Aggregate
+	OriginCenter, DestinationCenter GeoCoord
+}
+
+// flowKey is an (origin, destination) pair of cells.
+type flowKey struct {
+	origin, destination h3.Index
+}
+
+// FlowMatrix accumulates trips between PlaceKeys and rolls them up to coarser
+// levels. The what part of the PlaceKeys is dropped.
+//
+// Like Aggregator, partial matrices are combined with Merge, and a FlowMatrix
+// is safe for concurrent use.
+type FlowMatrix struct {
+	mu    sync.RWMutex
+	flows map[flowKey]Aggregate
+}
+
+// NewFlowMatrix returns an empty FlowMatrix.
+func NewFlowMatrix() *FlowMatrix {
+	return &FlowMatrix{flows: map[flowKey]Aggregate{}}
+}
+
+// Add counts a trip, with a value such as its duration.
+func (m *FlowMatrix) Add(origin, destination string, value float64) error {
+	return m.AddAggregate(origin, destination, Aggregate{Count: 1, Sum: value})
+}
+
+// AddAggregate adds an aggregate of trips computed elsewhere.
+func (m *FlowMatrix) AddAggregate(origin, destination string, agg Aggregate) error {
+	var k flowKey
+	for _, end := range []struct {
+		placeKey string
+		x        *h3.Index
+	}{{origin, &k.origin}, {destination, &k.destination}} {
+		if !FormatIsValid(end.placeKey) {
+			return ErrInvalidFormat
+		}
+		x, err := ToH3Index(end.placeKey)
+		if err != nil {
+			return err
+		}
+		*end.x = x
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.flows[k] = m.flows[k].add(agg)
+	return nil
+}
+
+// Merge adds the trips of another FlowMatrix.
+func (m *FlowMatrix) Merge(other *FlowMatrix) {
+	flows := other.snapshot()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for k, agg := range flows {
+		m.flows[k] = m.flows[k].add(agg)
+	}
+}
+
+// Len returns the number of (origin, destination) pairs with trips.
+func (m *FlowMatrix) Len() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return len(m.flows)
+}
+
+// Flows returns the flows between PlaceKeys, sorted by origin and
+// destination.
+func (m *FlowMatrix) Flows(c *H3) []Flow {
+	return m.rollup(c, func(x h3.Index) string { return encodeH3Int(uint64(x)) }, nil)
+}
+
+// Rollup returns the flows between parent H3 cells at a resolution from 0 to
+// 10, keyed by H3 string, sorted by origin and destination.
+func (m *FlowMatrix) Rollup(c *H3, res int) ([]Flow, error) {
+	if res < 0 || res > resolution {
+		return nil, ErrInvalidResolution
+	}
+	return m.rollup(c, func(x h3.Index) string {
+		return strconv.FormatUint(uint64(c.h3.ToParent(x, res)), 16)
+	}, func(key string) GeoCoord {
+		x, _ := strconv.ParseUint(key, 16, 64)
+		return c.h3.ToGeo(h3.Index(x))
+	}), nil
+}
+
+// RollupPrefix returns the flows between PlaceKey prefixes of a length from 1
+// to 9 characters, like Aggregator.RollupPrefix, sorted by origin and
+// destination. The center of a prefix is the centroid of the centers of its
+// PlaceKeys with trips.
+func (m *FlowMatrix) RollupPrefix(c *H3, length int) ([]Flow, error) {
+	if length < 1 || length > whereCodeLength {
+		return nil, ErrInvalidPrefixLength
+	}
+	return m.rollup(c, func(x h3.Index) string {
+		return placeKeyPrefix(encodeH3Int(uint64(x)), length)
+	}, nil), nil
+}
+
+// rollup groups the flows by the keys of their ends. The centers of the keys
+// are computed by center, or as the centroid of the cells of each key when
+// center is nil.
+func (m *FlowMatrix) rollup(c *H3, key func(h3.Index) string, center func(string) GeoCoord) []Flow {
+	type pair struct{ origin, destination string }
+	groups := map[pair]Aggregate{}
+	cells := map[string]map[h3.Index]bool{}
+	for k, agg := range m.snapshot() {
+		p := pair{key(k.origin), key(k.destination)}
+		groups[p] = groups[p].add(agg)
+		for _, end := range []struct {
+			key string
+			x   h3.Index
+		}{{p.origin, k.origin}, {p.destination, k.destination}} {
+			if cells[end.key] == nil {
+				cells[end.key] = map[h3.Index]bool{}
+			}
+			cells[end.key][end.x] = true
+		}
+	}
+	centers := make(map[string]GeoCoord, len(cells))
+	for k, xs := range cells {
+		if center != nil {
+			centers[k] = center(k)
+			continue
+		}
+		var vx, vy, vz float64
+		for x := range xs {
+			g := c.h3.ToGeo(x)
+			lat, lng := radians(g.Latitude), radians(g.Longitude)
+			vx += math.Cos(lat) * math.Cos(lng)
+			vy += math.Cos(lat) * math.Sin(lng)
+			vz += math.Sin(lat)
+		}
+		centers[k] = GeoCoord{
+			Latitude:  degrees(math.Atan2(vz, math.Hypot(vx, vy))),
+			Longitude: degrees(math.Atan2(vy, vx)),
+		}
+	}
+	flows := make([]Flow, 0, len(groups))
+	for p, agg := range groups {
+		flows = append(flows, Flow{
+			Origin:            p.origin,
+			Destination:       p.destination,
+			Aggregate:         agg,
+			OriginCenter:      centers[p.origin],
+			DestinationCenter: centers[p.destination],
+		})
+	}
+	sort.Slice(flows, func(i, j int) bool {
+		if flows[i].Origin != flows[j].Origin {
+			return flows[i].Origin < flows[j].Origin
+		}
+		return flows[i].Destination < flows[j].Destination
+	})
+	return flows
+}
+
+func (m *FlowMatrix) snapshot() map[flowKey]Aggregate {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	flows := make(map[flowKey]Aggregate, len(m.flows))
+	for k, agg := range m.flows {
+		flows[k] = agg
+	}
+	return flows
+}
+
+// TopFlows returns the n largest flows of each origin by count, sorted by
+// origin and decreasing count. Ties are broken by destination.
+func TopFlows(flows []Flow, n int) []Flow {
+	sorted := append([]Flow(nil), flows...)
+	sort.Slice(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		if a.Origin != b.Origin {
+			return a.Origin < b.Origin
+		}
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.Destination < b.Destination
+	})
+	out := []Flow{}
+	rank := 0
+	for i, f := range sorted {
+		if i == 0 || f.Origin != sorted[i-1].Origin {
+			rank = 0
+		}
+		if rank < n {
+			out = append(out, f)
+		}
+		rank++
+	}
+	return out
+}
+
+// WriteFlowsCSV writes flows as CSV, with an origin, destination, count and
+// sum header.
+func WriteFlowsCSV(w io.Writer, flows []Flow) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"origin", "destination", "count", "sum"}); err != nil {
+		return err
+	}
+	for _, f := range flows {
+		err := cw.Write([]string{
+			f.Origin,
+			f.Destination,
+			strconv.FormatInt(f.Count, 10),
+			formatFloat(f.Sum),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// FlowsGeoJSON returns flows as a GeoJSON FeatureCollection of LineStrings
+// from the center of their origin to the center of their destination.
+func FlowsGeoJSON(flows []Flow) ([]byte, error) {
+	type geometry struct {
+		Type        string      `json:"type"`
+		Coordinates [][]float64 `json:"coordinates"`
+	}
+	type properties struct {
+		Origin      string  `json:"origin"`
+		Destination string  `json:"destination"`
+		Count       int64   `json:"count"`
+		Sum         float64 `json:"sum"`
+	}
+	type feature struct {
+		Type       string     `json:"type"`
+		Geometry   geometry   `json:"geometry"`
+		Properties properties `json:"properties"`
+	}
+	features := make([]feature, 0, len(flows))
+	for _, f := range flows {
+		features = append(features, feature{
+			Type: "Feature",
+			Geometry: geometry{
+				Type: "LineString",
+				Coordinates: [][]float64{
+					{f.OriginCenter.Longitude, f.OriginCenter.Latitude},
+					{f.DestinationCenter.Longitude, f.DestinationCenter.Latitude},
+				},
+			},
+			Properties: properties{Origin: f.Origin, Destination: f.Destination, Count: f.Count, Sum: f.Sum},
+		})
+	}
+	return json.Marshal(struct {
+		Type     string    `json:"type"`
+		Features []feature `json:"features"`
+	}{
+		Type:     "FeatureCollection",
+		Features: features,
+	})
+}
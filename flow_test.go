@@ -0,0 +1,269 @@
+package placekey
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func testFlowMatrix(t *testing.T) *FlowMatrix {
+	t.Helper()
+	m := NewFlowMatrix()
+	for _, v := range []struct {
+		origin, destination string
+		value               float64
+	}{
+		{"@5vg-7gq-tvz", "@5vg-7gt-qzz", 10},
+		{"zzw-222@5vg-7gq-tvz", "@5vg-7gt-qzz", 20},
+		{"@5vg-7gq-tvz", "@5vg-82n-kzz", 30},
+		{"@5vg-7gt-qzz", "@5vg-7gq-tvz", 40},
+	} {
+		if err := m.Add(v.origin, v.destination, v.value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return m
+}
+
+func TestFlowMatrix(t *testing.T) {
+	c := NewH3()
+	defer c.Close()
+	m := testFlowMatrix(t)
+	if err := m.Add("invalid", "@5vg-7gt-qzz", 1); !errors.Is(err, ErrInvalidFormat) {
+		t.Errorf("Add() error = %v", err)
+	}
+	if err := m.Add("@5vg-7gt-qzz", "invalid", 1); !errors.Is(err, ErrInvalidFormat) {
+		t.Errorf("Add() error = %v", err)
+	}
+	if m.Len() != 3 {
+		t.Errorf("Len() got = %v", m.Len())
+	}
+	got := m.Flows(c)
+	want := []Flow{
+		{Origin: "@5vg-7gq-tvz", Destination: "@5vg-7gt-qzz", Aggregate: Aggregate{Count: 2, Sum: 30}},
+		{Origin: "@5vg-7gq-tvz", Destination: "@5vg-82n-kzz", Aggregate: Aggregate{Count: 1, Sum: 30}},
+		{Origin: "@5vg-7gt-qzz", Destination: "@5vg-7gq-tvz", Aggregate: Aggregate{Count: 1, Sum: 40}},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Flows() got = %+v", got)
+	}
+	for i, f := range got {
+		if f.Origin != want[i].Origin || f.Destination != want[i].Destination || f.Aggregate != want[i].Aggregate {
+			t.Errorf("Flows()[%d] got = %+v, want %+v", i, f, want[i])
+		}
+		for _, end := range []struct {
+			placeKey string
+			center   GeoCoord
+		}{{f.Origin, f.OriginCenter}, {f.Destination, f.DestinationCenter}} {
+			lat, lng, err := c.ToGeo(end.placeKey)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !almostEqual(end.center.Latitude, lat) || !almostEqual(end.center.Longitude, lng) {
+				t.Errorf("Flows() center of %s got = %+v", end.placeKey, end.center)
+			}
+		}
+	}
+}
+
+func TestFlowMatrix_Rollup(t *testing.T) {
+	c := NewH3()
+	defer c.Close()
+	m := NewFlowMatrix()
+	placeKeys := testPlaceKeys(t, c, 200)
+	for i, pk := range placeKeys {
+		if err := m.Add(pk, placeKeys[(i*7)%len(placeKeys)], float64(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	total := Aggregate{Count: 200, Sum: 199 * 200 / 2}
+	for res := 0; res <= 10; res++ {
+		got, err := m.Rollup(c, res)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sum := Aggregate{}
+		for _, f := range got {
+			sum = sum.add(f.Aggregate)
+		}
+		if sum != total {
+			t.Errorf("Rollup(%d) got total %+v", res, sum)
+		}
+	}
+	got, err := m.Rollup(c, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].Origin != got[0].Destination {
+		t.Errorf("Rollup(0) got = %+v", got)
+	}
+	if _, err := m.Rollup(c, 11); !errors.Is(err, ErrInvalidResolution) {
+		t.Errorf("Rollup() error = %v", err)
+	}
+}
+
+func TestFlowMatrix_RollupPrefix(t *testing.T) {
+	c := NewH3()
+	defer c.Close()
+	m := testFlowMatrix(t)
+	got, err := m.RollupPrefix(c, 6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct {
+		origin, destination string
+		agg                 Aggregate
+	}{
+		{"@5vg-7gq", "@5vg-7gt", Aggregate{Count: 2, Sum: 30}},
+		{"@5vg-7gq", "@5vg-82n", Aggregate{Count: 1, Sum: 30}},
+		{"@5vg-7gt", "@5vg-7gq", Aggregate{Count: 1, Sum: 40}},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("RollupPrefix(6) got = %+v", got)
+	}
+	for i, f := range got {
+		if f.Origin != want[i].origin || f.Destination != want[i].destination || f.Aggregate != want[i].agg {
+			t.Errorf("RollupPrefix(6)[%d] got = %+v", i, f)
+		}
+	}
+	got, err = m.RollupPrefix(c, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].Origin != "@5vg" || got[0].Count != 4 {
+		t.Fatalf("RollupPrefix(3) got = %+v", got)
+	}
+	// the center of a prefix lies between its PlaceKeys
+	lat1, _, _ := c.ToGeo("@5vg-7gq-tvz")
+	lat2, _, _ := c.ToGeo("@5vg-82n-kzz")
+	if lat := got[0].OriginCenter.Latitude; lat < lat1 && lat < lat2 || lat > lat1 && lat > lat2 {
+		t.Errorf("RollupPrefix(3) center got = %+v", got[0].OriginCenter)
+	}
+	for _, length := range []int{0, 10} {
+		if _, err := m.RollupPrefix(c, length); !errors.Is(err, ErrInvalidPrefixLength) {
+			t.Errorf("RollupPrefix(%d) error = %v", length, err)
+		}
+	}
+}
+
+func TestFlowMatrix_Merge(t *testing.T) {
+	c := NewH3()
+	defer c.Close()
+	placeKeys := testPlaceKeys(t, c, 100)
+	whole := NewFlowMatrix()
+	for i, pk := range placeKeys {
+		if err := whole.Add(pk, placeKeys[len(placeKeys)-1-i], float64(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	merged := NewFlowMatrix()
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			part := NewFlowMatrix()
+			for i := w; i < len(placeKeys); i += 4 {
+				if err := part.Add(placeKeys[i], placeKeys[len(placeKeys)-1-i], float64(i)); err != nil {
+					t.Error(err)
+				}
+			}
+			merged.Merge(part)
+		}(w)
+	}
+	wg.Wait()
+	if !reflect.DeepEqual(merged.Flows(c), whole.Flows(c)) {
+		t.Error("Merge() got different flows")
+	}
+}
+
+func TestTopFlows(t *testing.T) {
+	flows := []Flow{
+		{Origin: "a", Destination: "x", Aggregate: Aggregate{Count: 1}},
+		{Origin: "b", Destination: "x", Aggregate: Aggregate{Count: 5}},
+		{Origin: "a", Destination: "y", Aggregate: Aggregate{Count: 3}},
+		{Origin: "a", Destination: "z", Aggregate: Aggregate{Count: 3}},
+		{Origin: "b", Destination: "y", Aggregate: Aggregate{Count: 7}},
+	}
+	tests := []struct {
+		n    int
+		want []string
+	}{
+		{0, []string{}},
+		{1, []string{"a-y", "b-y"}},
+		{2, []string{"a-y", "a-z", "b-y", "b-x"}},
+		{10, []string{"a-y", "a-z", "a-x", "b-y", "b-x"}},
+	}
+	for _, tt := range tests {
+		got := []string{}
+		for _, f := range TopFlows(flows, tt.n) {
+			got = append(got, f.Origin+"-"+f.Destination)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("TopFlows(%d) got = %v, want %v", tt.n, got, tt.want)
+		}
+	}
+	if flows[0].Destination != "x" || flows[1].Origin != "b" {
+		t.Error("TopFlows() modified its input")
+	}
+}
+
+func TestWriteFlowsCSV(t *testing.T) {
+	flows := []Flow{
+		{Origin: "@5vg-7gq-tvz", Destination: "@5vg-7gt-qzz", Aggregate: Aggregate{Count: 2, Sum: 30.5}},
+		{Origin: "@5vg-7gt-qzz", Destination: "@5vg-7gq-tvz", Aggregate: Aggregate{Count: 1, Sum: 40}},
+	}
+	var buf bytes.Buffer
+	if err := WriteFlowsCSV(&buf, flows); err != nil {
+		t.Fatal(err)
+	}
+	want := "origin,destination,count,sum\n" +
+		"@5vg-7gq-tvz,@5vg-7gt-qzz,2,30.5\n" +
+		"@5vg-7gt-qzz,@5vg-7gq-tvz,1,40\n"
+	if buf.String() != want {
+		t.Errorf("WriteFlowsCSV() got = %q, want %q", buf.String(), want)
+	}
+}
+
+func TestFlowsGeoJSON(t *testing.T) {
+	flows := []Flow{{
+		Origin:            "@5vg-7gq-tvz",
+		Destination:       "@5vg-7gt-qzz",
+		Aggregate:         Aggregate{Count: 2, Sum: 30},
+		OriginCenter:      GeoCoord{Latitude: 37.78, Longitude: -122.41},
+		DestinationCenter: GeoCoord{Latitude: 37.79, Longitude: -122.40},
+	}}
+	b, err := FlowsGeoJSON(flows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got struct {
+		Type     string
+		Features []struct {
+			Type     string
+			Geometry struct {
+				Type        string
+				Coordinates [][]float64
+			}
+			Properties map[string]interface{}
+		}
+	}
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Type != "FeatureCollection" || len(got.Features) != 1 {
+		t.Fatalf("FlowsGeoJSON() got = %s", b)
+	}
+	f := got.Features[0]
+	if f.Geometry.Type != "LineString" ||
+		!reflect.DeepEqual(f.Geometry.Coordinates, [][]float64{{-122.41, 37.78}, {-122.40, 37.79}}) {
+		t.Errorf("FlowsGeoJSON() geometry got = %+v", f.Geometry)
+	}
+	if f.Properties["origin"] != "@5vg-7gq-tvz" || f.Properties["destination"] != "@5vg-7gt-qzz" ||
+		f.Properties["count"] != 2.0 || f.Properties["sum"] != 30.0 {
+		t.Errorf("FlowsGeoJSON() properties got = %v", f.Properties)
+	}
+}
@@ -0,0 +1,296 @@
+package placekey
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/diegosz/placekey-go/internal/h3"
+)
+
+var ErrInvalidPolygon = errors.New("invalid polygon")
+var ErrDuplicateFence = errors.New("duplicate fence")
+
+// Fence is a named polygon. Its loops must span less than 180 degrees of
+// longitude.
+type Fence struct {
+	Name    string
+	Polygon GeoPolygon
+}
+
+// FenceSet is an immutable set of fences converted to PlaceKeys.
+//
+// Each fence is split into interior cells, entirely inside it, and boundary
+// cells, crossed by one of its loops. A point in an interior cell is inside the
+// fence, a point in a boundary cell is tested against the polygon itself, and
+// any other point is outside.
+type FenceSet struct {
+	fences   []Fence
+	interior map[h3.Index][]int
+	boundary map[h3.Index][]int
+}
+
+// NewFenceSet converts fences into a FenceSet. Fence names must be unique.
+func (c *H3) NewFenceSet(fences []Fence) (*FenceSet, error) {
+	s := &FenceSet{
+		fences:   append([]Fence(nil), fences...),
+		interior: map[h3.Index][]int{},
+		boundary: map[h3.Index][]int{},
+	}
+	names := map[string]bool{}
+	for i, f := range s.fences {
+		if names[f.Name] {
+			return nil, ErrDuplicateFence
+		}
+		names[f.Name] = true
+		boundary, err := c.fenceBoundary(f.Polygon)
+		if err != nil {
+			return nil, err
+		}
+		if float64(c.h3.MaxPolyfillSize(f.Polygon, resolution)) > maxCoverCells {
+			return nil, ErrCoverTooLarge
+		}
+		for _, x := range c.h3.Polyfill(f.Polygon, resolution) {
+			if !boundary[x] {
+				s.interior[x] = append(s.interior[x], i)
+			}
+		}
+		for x := range boundary {
+			s.boundary[x] = append(s.boundary[x], i)
+		}
+	}
+	return s, nil
+}
+
+// fenceBoundary returns the cells crossed by the loops of a polygon, flooding
+// the grid from the first vertex of each loop.
+func (c *H3) fenceBoundary(polygon GeoPolygon) (map[h3.Index]bool, error) {
+	loops := append([][]GeoCoord{polygon.Geofence}, polygon.Holes...)
+	boundary := map[h3.Index]bool{}
+	for _, loop := range loops {
+		if len(loop) < 3 {
+			return nil, ErrInvalidPolygon
+		}
+		for _, g := range loop {
+			if g.Latitude < -90 || g.Latitude > 90 || g.Longitude < -180 || g.Longitude > 180 {
+				return nil, ErrInvalidLatLngRange
+			}
+		}
+		// each loop is flooded on its own, since the cells it shares with
+		// the other loops do not tell where it goes
+		seed := c.h3.FromGeo(loop[0], resolution)
+		visited := map[h3.Index]bool{seed: true}
+		boundary[seed] = true
+		queue := []h3.Index{seed}
+		for len(queue) > 0 {
+			x := queue[0]
+			queue = queue[1:]
+			for _, n := range c.h3.KRing(x, 1) {
+				if visited[n] {
+					continue
+				}
+				_, lng, ring := c.cellGeo(n)
+				if !ringCrossesLoop(unwrapRing(ring, lng), loopPoints(loop, lng)) {
+					continue
+				}
+				visited[n] = true
+				boundary[n] = true
+				queue = append(queue, n)
+				if float64(len(boundary)) > maxCoverCells {
+					return nil, ErrCoverTooLarge
+				}
+			}
+		}
+	}
+	return boundary, nil
+}
+
+// Len returns the number of fences.
+func (s *FenceSet) Len() int {
+	return len(s.fences)
+}
+
+// Fences returns the fences of the set.
+func (s *FenceSet) Fences() []Fence {
+	return append([]Fence(nil), s.fences...)
+}
+
+// PlaceKeys returns the sorted PlaceKeys of the interior and boundary cells of
+// a fence, nil when there is no fence with this name.
+func (s *FenceSet) PlaceKeys(name string) []string {
+	fence := -1
+	for i, f := range s.fences {
+		if f.Name == name {
+			fence = i
+		}
+	}
+	if fence < 0 {
+		return nil
+	}
+	pks := []string{}
+	for _, cells := range []map[h3.Index][]int{s.interior, s.boundary} {
+		for x, fences := range cells {
+			for _, i := range fences {
+				if i == fence {
+					pks = append(pks, encodeH3Int(uint64(x)))
+				}
+			}
+		}
+	}
+	sort.Strings(pks)
+	return pks
+}
+
+// Lookup returns the sorted names of the fences containing a (latitude,
+// longitude).
+func (s *FenceSet) Lookup(c *H3, lat, lng float64) ([]string, error) {
+	if lat < -90 || lat > 90 || lng < -180 || lng > 180 {
+		return nil, ErrInvalidLatLngRange
+	}
+	x := c.h3.FromGeo(h3.GeoCoord{Latitude: lat, Longitude: lng}, resolution)
+	names := []string{}
+	for _, i := range s.interior[x] {
+		names = append(names, s.fences[i].Name)
+	}
+	for _, i := range s.boundary[x] {
+		if polygonContains(s.fences[i].Polygon, lat, lng) {
+			names = append(names, s.fences[i].Name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// FenceEventType is the type of a FenceEvent.
+type FenceEventType int
+
+const (
+	// FenceEnter is sent when a device enters a fence.
+	FenceEnter FenceEventType = iota
+	// FenceExit is sent when a device leaves a fence.
+	FenceExit
+	// FenceDwell is sent once per stay, when a device has been in a fence for
+	// the dwell time of the Geofencer.
+	FenceDwell
+)
+
+func (t FenceEventType) String() string {
+	switch t {
+	case FenceEnter:
+		return "enter"
+	case FenceExit:
+		return "exit"
+	case FenceDwell:
+		return "dwell"
+	default:
+		return "unknown"
+	}
+}
+
+// FenceEvent is a change of the position of a device relative to a fence. Time
+// is the time of the ping triggering the event and Dwell the time elapsed
+// since the device entered the fence.
+type FenceEvent struct {
+	Type   FenceEventType
+	Device string
+	Fence  string
+	Time   time.Time
+	Dwell  time.Duration
+}
+
+// fenceStay is the stay of a device in a fence.
+type fenceStay struct {
+	enter   time.Time
+	dwelled bool
+}
+
+// Geofencer turns streams of device positions into fence events.
+//
+// A Geofencer is safe for concurrent use. Its FenceSet can be replaced at any
+// time: stays in fences keeping their name carry over to the new set, and
+// devices in fences that are no longer present exit them at their next ping.
+type Geofencer struct {
+	fences  atomic.Value
+	dwell   time.Duration
+	mu      sync.Mutex
+	devices map[string]map[string]*fenceStay
+}
+
+// NewGeofencer returns a Geofencer over a FenceSet, sending dwell events after
+// a dwell time, none when zero.
+func NewGeofencer(fences *FenceSet, dwell time.Duration) *Geofencer {
+	g := &Geofencer{dwell: dwell, devices: map[string]map[string]*fenceStay{}}
+	g.fences.Store(fences)
+	return g
+}
+
+// Fences returns the current FenceSet.
+func (g *Geofencer) Fences() *FenceSet {
+	return g.fences.Load().(*FenceSet)
+}
+
+// SetFences replaces the FenceSet.
+func (g *Geofencer) SetFences(fences *FenceSet) {
+	g.fences.Store(fences)
+}
+
+// Push processes a ping of a device and returns the events it triggers: exits
+// first, then enters, then dwells, each sorted by fence name. The pings of a
+// device must be pushed in time order.
+func (g *Geofencer) Push(c *H3, device string, p Ping) ([]FenceEvent, error) {
+	names, err := g.Fences().Lookup(c, p.Lat, p.Lng)
+	if err != nil {
+		return nil, err
+	}
+	inside := make(map[string]bool, len(names))
+	for _, name := range names {
+		inside[name] = true
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	stays := g.devices[device]
+	if stays == nil {
+		stays = map[string]*fenceStay{}
+	}
+	events := []FenceEvent{}
+	exits := []string{}
+	for name := range stays {
+		if !inside[name] {
+			exits = append(exits, name)
+		}
+	}
+	sort.Strings(exits)
+	for _, name := range exits {
+		events = append(events, FenceEvent{Type: FenceExit, Device: device, Fence: name, Time: p.Time, Dwell: p.Time.Sub(stays[name].enter)})
+		delete(stays, name)
+	}
+	for _, name := range names {
+		if stays[name] == nil {
+			stays[name] = &fenceStay{enter: p.Time}
+			events = append(events, FenceEvent{Type: FenceEnter, Device: device, Fence: name, Time: p.Time})
+		}
+	}
+	for _, name := range names {
+		stay := stays[name]
+		if d := p.Time.Sub(stay.enter); g.dwell > 0 && !stay.dwelled && d >= g.dwell {
+			stay.dwelled = true
+			events = append(events, FenceEvent{Type: FenceDwell, Device: device, Fence: name, Time: p.Time, Dwell: d})
+		}
+	}
+	if len(stays) == 0 {
+		delete(g.devices, device)
+	} else {
+		g.devices[device] = stays
+	}
+	return events, nil
+}
+
+// Forget drops the state of a device, without sending exit events.
+func (g *Geofencer) Forget(device string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	delete(g.devices, device)
+}
@@ -0,0 +1,232 @@
+package placekey
+
+import (
+	"errors"
+	"math/rand"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// testFences returns a square fence around SF City Hall, with a hole, and a
+// triangle smaller than a cell next to it.
+func testFences() []Fence {
+	return []Fence{
+		{
+			Name: "civic center",
+			Polygon: GeoPolygon{
+				Geofence: []GeoCoord{
+					{Latitude: 37.7765, Longitude: -122.4230},
+					{Latitude: 37.7765, Longitude: -122.4155},
+					{Latitude: 37.7820, Longitude: -122.4155},
+					{Latitude: 37.7820, Longitude: -122.4230},
+				},
+				Holes: [][]GeoCoord{{
+					{Latitude: 37.7775, Longitude: -122.4220},
+					{Latitude: 37.7775, Longitude: -122.4205},
+					{Latitude: 37.7785, Longitude: -122.4205},
+					{Latitude: 37.7785, Longitude: -122.4220},
+				}},
+			},
+		},
+		{
+			Name: "corner",
+			Polygon: GeoPolygon{
+				Geofence: []GeoCoord{
+					{Latitude: 37.7810, Longitude: -122.4165},
+					{Latitude: 37.7810, Longitude: -122.4160},
+					{Latitude: 37.7814, Longitude: -122.4160},
+				},
+			},
+		},
+	}
+}
+
+func TestH3_NewFenceSet(t *testing.T) {
+	c := NewH3()
+	defer c.Close()
+	s, err := c.NewFenceSet(testFences())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Len() != 2 {
+		t.Errorf("Len() got = %v", s.Len())
+	}
+	pks := s.PlaceKeys("civic center")
+	if !containsString(pks, "@5vg-7gq-tvz") || len(pks) < 20 {
+		t.Errorf("PlaceKeys() got %d cells: %v", len(pks), pks)
+	}
+	if len(s.interior) == 0 || len(s.boundary) == 0 {
+		t.Errorf("NewFenceSet() got %d interior and %d boundary cells", len(s.interior), len(s.boundary))
+	}
+	if pks := s.PlaceKeys("corner"); len(pks) == 0 || len(pks) > 3 {
+		t.Errorf("PlaceKeys() got = %v", pks)
+	}
+	if pks := s.PlaceKeys("unknown"); pks != nil {
+		t.Errorf("PlaceKeys() got = %v", pks)
+	}
+
+	invalid := []struct {
+		name   string
+		fences []Fence
+		err    error
+	}{
+		{"duplicate", []Fence{testFences()[0], testFences()[0]}, ErrDuplicateFence},
+		{"two vertices", []Fence{{Name: "a", Polygon: GeoPolygon{Geofence: []GeoCoord{{}, {Latitude: 1}}}}}, ErrInvalidPolygon},
+		{"out of range", []Fence{{Name: "a", Polygon: GeoPolygon{Geofence: []GeoCoord{{}, {Latitude: 1}, {Latitude: 91}}}}}, ErrInvalidLatLngRange},
+	}
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := c.NewFenceSet(tt.fences); !errors.Is(err, tt.err) {
+				t.Errorf("NewFenceSet() error = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestFenceSet_Lookup(t *testing.T) {
+	c := NewH3()
+	defer c.Close()
+	fences := testFences()
+	s, err := c.NewFenceSet(fences)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := s.Lookup(c, 37.7812, -122.4161)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"civic center", "corner"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Lookup() got = %v, want %v", got, want)
+	}
+	if got, _ := s.Lookup(c, 37.7780, -122.4212); len(got) != 0 {
+		t.Errorf("Lookup() in hole got = %v", got)
+	}
+	if _, err := s.Lookup(c, 91, 0); !errors.Is(err, ErrInvalidLatLngRange) {
+		t.Errorf("Lookup() error = %v", err)
+	}
+
+	// the set lookup agrees with the exact test everywhere around the fences
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 5000; i++ {
+		lat := 37.7755 + r.Float64()*0.0075
+		lng := -122.4240 + r.Float64()*0.0095
+		got, err := s.Lookup(c, lat, lng)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []string{}
+		for _, f := range fences {
+			if polygonContains(f.Polygon, lat, lng) {
+				want = append(want, f.Name)
+			}
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("Lookup(%v, %v) got = %v, want %v", lat, lng, got, want)
+		}
+	}
+}
+
+func TestFenceSet_Lookup_HoleNearBoundary(t *testing.T) {
+	c := NewH3()
+	defer c.Close()
+	// a hole starting in a cell crossed by the outer loop
+	fence := Fence{
+		Name: "square",
+		Polygon: GeoPolygon{
+			Geofence: []GeoCoord{
+				{Latitude: 0, Longitude: 0},
+				{Latitude: 0, Longitude: 0.1},
+				{Latitude: 0.1, Longitude: 0.1},
+				{Latitude: 0.1, Longitude: 0},
+			},
+			Holes: [][]GeoCoord{{
+				{Latitude: 0.05, Longitude: 0.0002},
+				{Latitude: 0.05, Longitude: 0.02},
+				{Latitude: 0.07, Longitude: 0.02},
+				{Latitude: 0.07, Longitude: 0.0002},
+			}},
+		},
+	}
+	s, err := c.NewFenceSet([]Fence{fence})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 2000; i++ {
+		lat := 0.045 + r.Float64()*0.03
+		lng := r.Float64() * 0.025
+		got, err := s.Lookup(c, lat, lng)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := polygonContains(fence.Polygon, lat, lng); (len(got) == 1) != want {
+			t.Fatalf("Lookup(%v, %v) got = %v, want inside %v", lat, lng, got, want)
+		}
+	}
+}
+
+func TestGeofencer_Push(t *testing.T) {
+	c := NewH3()
+	defer c.Close()
+	s, err := c.NewFenceSet(testFences())
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := NewGeofencer(s, 10*time.Minute)
+	t0 := time.Date(2022, 8, 1, 8, 0, 0, 0, time.UTC)
+	pings := []Ping{
+		{Time: t0, Lat: 37.7700, Lng: -122.4300},
+		{Time: t0.Add(time.Minute), Lat: 37.7800, Lng: -122.4190},
+		{Time: t0.Add(5 * time.Minute), Lat: 37.7812, Lng: -122.4161},
+		{Time: t0.Add(12 * time.Minute), Lat: 37.7800, Lng: -122.4190},
+		{Time: t0.Add(15 * time.Minute), Lat: 37.7800, Lng: -122.4190},
+		{Time: t0.Add(20 * time.Minute), Lat: 37.7700, Lng: -122.4300},
+	}
+	want := [][]FenceEvent{
+		{},
+		{{Type: FenceEnter, Fence: "civic center"}},
+		{{Type: FenceEnter, Fence: "corner"}},
+		{{Type: FenceExit, Fence: "corner", Dwell: 7 * time.Minute}, {Type: FenceDwell, Fence: "civic center", Dwell: 11 * time.Minute}},
+		{},
+		{{Type: FenceExit, Fence: "civic center", Dwell: 19 * time.Minute}},
+	}
+	for i, p := range pings {
+		got, err := g.Push(c, "device", p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for j := range want[i] {
+			want[i][j].Device = "device"
+			want[i][j].Time = p.Time
+		}
+		if !reflect.DeepEqual(got, want[i]) {
+			t.Errorf("Push() %d got = %v, want %v", i, got, want[i])
+		}
+	}
+
+	// swapping the fences exits the fences that disappeared
+	if _, err := g.Push(c, "device", Ping{Time: t0, Lat: 37.7812, Lng: -122.4161}); err != nil {
+		t.Fatal(err)
+	}
+	s2, err := c.NewFenceSet(testFences()[:1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.SetFences(s2)
+	if g.Fences() != s2 {
+		t.Error("Fences() got the previous set")
+	}
+	got, err := g.Push(c, "device", Ping{Time: t0.Add(time.Minute), Lat: 37.7812, Lng: -122.4161})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].Type != FenceExit || got[0].Fence != "corner" {
+		t.Errorf("Push() after swap got = %v", got)
+	}
+	g.Forget("device")
+	got, _ = g.Push(c, "device", Ping{Time: t0.Add(2 * time.Minute), Lat: 37.7812, Lng: -122.4161})
+	if len(got) != 1 || got[0].Type != FenceEnter {
+		t.Errorf("Push() after forget got = %v", got)
+	}
+}
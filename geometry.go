@@ -0,0 +1,177 @@
+package placekey
+
+import (
+	"math"
+)
+
+// point is a coordinate on a plane, either a projection of a geographic
+// coordinate or a (longitude, latitude) pair with unwrapped longitudes.
+type point struct {
+	x, y float64
+}
+
+// pointInRing returns whether or not a point is inside a ring using the even
+// odd rule. The ring does not need to be closed.
+func pointInRing(p point, ring []point) bool {
+	inside := false
+	for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+		a, b := ring[i], ring[j]
+		if (a.y > p.y) != (b.y > p.y) && p.x < (b.x-a.x)*(p.y-a.y)/(b.y-a.y)+a.x {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// segmentsIntersect returns whether or not the segments p1-p2 and p3-p4
+// intersect, touching included.
+func segmentsIntersect(p1, p2, p3, p4 point) bool {
+	d1 := cross(p3, p4, p1)
+	d2 := cross(p3, p4, p2)
+	d3 := cross(p1, p2, p3)
+	d4 := cross(p1, p2, p4)
+	if ((d1 > 0 && d2 < 0) || (d1 < 0 && d2 > 0)) && ((d3 > 0 && d4 < 0) || (d3 < 0 && d4 > 0)) {
+		return true
+	}
+	return (d1 == 0 && onSegment(p3, p4, p1)) ||
+		(d2 == 0 && onSegment(p3, p4, p2)) ||
+		(d3 == 0 && onSegment(p1, p2, p3)) ||
+		(d4 == 0 && onSegment(p1, p2, p4))
+}
+
+// cross returns the z component of the cross product of a-o and b-o.
+func cross(o, a, b point) float64 {
+	return (a.x-o.x)*(b.y-o.y) - (a.y-o.y)*(b.x-o.x)
+}
+
+// onSegment returns whether or not p, known to be collinear with a-b, lies
+// within the segment a-b.
+func onSegment(a, b, p point) bool {
+	return math.Min(a.x, b.x) <= p.x && p.x <= math.Max(a.x, b.x) &&
+		math.Min(a.y, b.y) <= p.y && p.y <= math.Max(a.y, b.y)
+}
+
+// pointSegmentDistance returns the distance between a point and the segment
+// a-b.
+func pointSegmentDistance(p, a, b point) float64 {
+	dx, dy := b.x-a.x, b.y-a.y
+	t := 0.0
+	if l := dx*dx + dy*dy; l > 0 {
+		t = math.Max(0, math.Min(1, ((p.x-a.x)*dx+(p.y-a.y)*dy)/l))
+	}
+	return math.Hypot(p.x-(a.x+t*dx), p.y-(a.y+t*dy))
+}
+
+// ringsIntersect returns whether or not two rings overlap, including when one
+// contains the other.
+func ringsIntersect(r1, r2 []point) bool {
+	if len(r1) == 0 || len(r2) == 0 {
+		return false
+	}
+	if pointInRing(r1[0], r2) || pointInRing(r2[0], r1) {
+		return true
+	}
+	for i := range r1 {
+		a, b := r1[i], r1[(i+1)%len(r1)]
+		for j := range r2 {
+			if segmentsIntersect(a, b, r2[j], r2[(j+1)%len(r2)]) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// azimuthalProjection projects a (latitude, longitude) on the azimuthal
+// equidistant plane centered on (lat0, lng0), in meters. Distances and
+// bearings from the center are preserved, so the projection is accurate near
+// the center whatever its position, poles and antimeridian included.
+func azimuthalProjection(lat0, lng0, lat, lng float64) point {
+	d := geoDistance(lat0, lng0, lat, lng)
+	b := radians(geoBearing(lat0, lng0, lat, lng))
+	return point{x: d * math.Sin(b), y: d * math.Cos(b)}
+}
+
+// unwrapRing converts a ring of (latitude, longitude) coordinates into
+// (longitude, latitude) points whose longitudes never jump by more than 180
+// degrees, shifted by a multiple of 360 degrees so that the first point lies
+// within 180 degrees of lng0.
+func unwrapRing(ring [][]float64, lng0 float64) []point {
+	pts := make([]point, 0, len(ring))
+	for i, c := range ring {
+		lng := c[1]
+		if i == 0 {
+			lng = lng0 + normalizeLng(lng-lng0)
+		} else {
+			prev := pts[i-1].x
+			lng = prev + normalizeLng(lng-prev)
+		}
+		pts = append(pts, point{x: lng, y: c[0]})
+	}
+	return pts
+}
+
+// boundaryDistance returns the distance in meters from a (latitude, longitude)
+// to the nearest point of a ring of (latitude, longitude) coordinates, or zero
+// when the coordinate is inside the ring.
+func boundaryDistance(lat, lng float64, ring [][]float64) float64 {
+	origin := point{}
+	pts := make([]point, 0, len(ring))
+	for _, v := range ring {
+		pts = append(pts, azimuthalProjection(lat, lng, v[0], v[1]))
+	}
+	if pointInRing(origin, pts) {
+		return 0
+	}
+	d := math.Inf(1)
+	for i := range pts {
+		d = math.Min(d, pointSegmentDistance(origin, pts[i], pts[(i+1)%len(pts)]))
+	}
+	return d
+}
+
+// polygonContains returns whether or not a (latitude, longitude) is inside a
+// polygon and outside its holes. Longitudes are unwrapped around the
+// coordinate, so the polygon must span less than 180 degrees of longitude.
+func polygonContains(polygon GeoPolygon, lat, lng float64) bool {
+	p := point{x: lng, y: lat}
+	if !pointInRing(p, loopPoints(polygon.Geofence, lng)) {
+		return false
+	}
+	for _, h := range polygon.Holes {
+		if pointInRing(p, loopPoints(h, lng)) {
+			return false
+		}
+	}
+	return true
+}
+
+// loopPoints converts a loop into (longitude, latitude) points unwrapped as
+// by unwrapRing.
+func loopPoints(loop []GeoCoord, lng0 float64) []point {
+	ring := make([][]float64, 0, len(loop))
+	for _, g := range loop {
+		ring = append(ring, []float64{g.Latitude, g.Longitude})
+	}
+	return unwrapRing(ring, lng0)
+}
+
+// ringCrossesLoop returns whether or not the boundary of a loop passes through
+// a ring, either crossing or touching its edges or lying inside it.
+func ringCrossesLoop(ring, loop []point) bool {
+	if len(ring) == 0 || len(loop) == 0 {
+		return false
+	}
+	if pointInRing(loop[0], ring) {
+		return true
+	}
+	for i := range ring {
+		a, b := ring[i], ring[(i+1)%len(ring)]
+		for j := range loop {
+			if segmentsIntersect(a, b, loop[j], loop[(j+1)%len(loop)]) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,247 @@
+//nolint:gomnd
+package placekey
+
+import (
+	"bufio"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TrackPoint kinds.
+const (
+	KindWaypoint   = "wpt"
+	KindRoutePoint = "rtept"
+	KindTrackPoint = "trkpt"
+	KindFix        = "fix"
+)
+
+var ErrInvalidNMEA = errors.New("invalid NMEA sentence")
+
+// TrackPoint is a position read from a GPS file, with its PlaceKey.
+//
+// Kind tells where the point comes from: a GPX waypoint, route point or track
+// point, or an NMEA fix. Name is the name of the waypoint, route or track. The
+// time is zero when the file does not provide it.
+type TrackPoint struct {
+	Ping
+	PlaceKey string
+	Kind     string
+	Name     string
+}
+
+type gpxPoint struct {
+	Lat  float64 `xml:"lat,attr"`
+	Lon  float64 `xml:"lon,attr"`
+	Time string  `xml:"time"`
+	Name string  `xml:"name"`
+}
+
+type gpxFile struct {
+	Waypoints []gpxPoint `xml:"wpt"`
+	Routes    []struct {
+		Name   string     `xml:"name"`
+		Points []gpxPoint `xml:"rtept"`
+	} `xml:"rte"`
+	Tracks []struct {
+		Name     string `xml:"name"`
+		Segments []struct {
+			Points []gpxPoint `xml:"trkpt"`
+		} `xml:"trkseg"`
+	} `xml:"trk"`
+}
+
+// ReadGPX reads the waypoints, routes and tracks of a GPX 1.1 file, in this
+// order, and returns their points with their PlaceKeys.
+func (c *H3) ReadGPX(r io.Reader) ([]TrackPoint, error) {
+	var gpx gpxFile
+	if err := xml.NewDecoder(r).Decode(&gpx); err != nil {
+		return nil, err
+	}
+	points := []TrackPoint{}
+	add := func(p gpxPoint, kind, name string) error {
+		tp := TrackPoint{Ping: Ping{Lat: p.Lat, Lng: p.Lon}, Kind: kind, Name: name}
+		if t := strings.TrimSpace(p.Time); t != "" {
+			var err error
+			if tp.Time, err = time.Parse(time.RFC3339, t); err != nil {
+				return err
+			}
+		}
+		pk, err := c.FromGeo(tp.Lat, tp.Lng)
+		if err != nil {
+			return err
+		}
+		tp.PlaceKey = pk
+		points = append(points, tp)
+		return nil
+	}
+	for _, w := range gpx.Waypoints {
+		if err := add(w, KindWaypoint, w.Name); err != nil {
+			return nil, err
+		}
+	}
+	for _, rte := range gpx.Routes {
+		for _, p := range rte.Points {
+			if err := add(p, KindRoutePoint, rte.Name); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for _, trk := range gpx.Tracks {
+		for _, seg := range trk.Segments {
+			for _, p := range seg.Points {
+				if err := add(p, KindTrackPoint, trk.Name); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	return points, nil
+}
+
+// ReadNMEA reads the fixes of the GGA and RMC sentences of an NMEA 0183 log,
+// from any talker, and returns them with their PlaceKeys.
+//
+// GGA and RMC sentences reporting the same fix are merged into one point.
+// Sentences with an invalid checksum, and fixes reported as invalid, are
+// skipped. GGA sentences only carry the time of day, so their date is taken
+// from the latest RMC sentence; fixes read before any RMC sentence have a zero
+// date.
+func (c *H3) ReadNMEA(r io.Reader) ([]TrackPoint, error) {
+	points := []TrackPoint{}
+	var date time.Time
+	var clock time.Duration
+	hasFix := false
+	s := bufio.NewScanner(r)
+	line := 0
+	for s.Scan() {
+		line++
+		fields, ok := nmeaFields(strings.TrimSpace(s.Text()))
+		if !ok || len(fields[0]) < 5 {
+			continue
+		}
+		var fixClock time.Duration
+		var lat, lng float64
+		var err error
+		switch fields[0][len(fields[0])-3:] {
+		case "GGA":
+			if len(fields) < 7 || fields[6] == "" || fields[6] == "0" {
+				continue
+			}
+			fixClock, lat, lng, err = nmeaFix(fields[1], fields[2], fields[3], fields[4], fields[5])
+			if err == nil && hasFix && fixClock < clock && !date.IsZero() {
+				// the time of day went backwards, midnight passed since the
+				// latest RMC sentence
+				date = date.AddDate(0, 0, 1)
+			}
+		case "RMC":
+			if len(fields) < 10 || fields[2] != "A" {
+				continue
+			}
+			fixClock, lat, lng, err = nmeaFix(fields[1], fields[3], fields[4], fields[5], fields[6])
+			if err == nil {
+				var d time.Time
+				if d, err = time.Parse("020106", fields[9]); err == nil {
+					date = d
+				}
+			}
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		t := date.Add(fixClock)
+		if hasFix && fixClock == clock {
+			// same fix reported by another sentence
+			points[len(points)-1].Time = t
+			continue
+		}
+		pk, err := c.FromGeo(lat, lng)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		points = append(points, TrackPoint{Ping: Ping{Time: t, Lat: lat, Lng: lng}, PlaceKey: pk, Kind: KindFix})
+		clock = fixClock
+		hasFix = true
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return points, nil
+}
+
+// nmeaFields returns the comma separated fields of an NMEA sentence, the first
+// one being its address, after checking its checksum when present.
+func nmeaFields(sentence string) ([]string, bool) {
+	if !strings.HasPrefix(sentence, "$") {
+		return nil, false
+	}
+	body := sentence[1:]
+	if i := strings.LastIndexByte(body, '*'); i >= 0 {
+		sum, err := strconv.ParseUint(body[i+1:], 16, 8)
+		if err != nil {
+			return nil, false
+		}
+		var x byte
+		for j := 0; j < i; j++ {
+			x ^= body[j]
+		}
+		if byte(sum) != x {
+			return nil, false
+		}
+		body = body[:i]
+	}
+	return strings.Split(body, ","), true
+}
+
+// nmeaFix parses the time of day and the coordinates of an NMEA fix.
+func nmeaFix(clock, lat, ns, lng, ew string) (time.Duration, float64, float64, error) {
+	if len(clock) < 6 {
+		return 0, 0, 0, ErrInvalidNMEA
+	}
+	h, err1 := strconv.Atoi(clock[0:2])
+	m, err2 := strconv.Atoi(clock[2:4])
+	sec, err3 := strconv.ParseFloat(clock[4:], 64)
+	if err1 != nil || err2 != nil || err3 != nil {
+		return 0, 0, 0, ErrInvalidNMEA
+	}
+	d := time.Duration(h)*time.Hour + time.Duration(m)*time.Minute + time.Duration(sec*float64(time.Second))
+	la, err := nmeaDegrees(lat, 2, ns, "N", "S")
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	lo, err := nmeaDegrees(lng, 3, ew, "E", "W")
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	return d, la, lo, nil
+}
+
+// nmeaDegrees parses an NMEA (d)ddmm.mmmm angle into decimal degrees.
+func nmeaDegrees(v string, degreeDigits int, hemisphere, positive, negative string) (float64, error) {
+	if len(v) < degreeDigits+2 {
+		return 0, ErrInvalidNMEA
+	}
+	d, err := strconv.Atoi(v[:degreeDigits])
+	if err != nil {
+		return 0, ErrInvalidNMEA
+	}
+	m, err := strconv.ParseFloat(v[degreeDigits:], 64)
+	if err != nil {
+		return 0, ErrInvalidNMEA
+	}
+	deg := float64(d) + m/60
+	switch hemisphere {
+	case positive:
+		return deg, nil
+	case negative:
+		return -deg, nil
+	default:
+		return 0, ErrInvalidNMEA
+	}
+}
@@ -0,0 +1,88 @@
+package placekey
+
+import (
+	"bytes"
+	_ "embed"
+	"strings"
+	"testing"
+	"time"
+)
+
+//go:embed test/example.gpx
+var exampleGPX []byte
+
+//go:embed test/example.nmea
+var exampleNMEA []byte
+
+func TestH3_ReadGPX(t *testing.T) {
+	c := NewH3()
+	defer c.Close()
+	got, err := c.ReadGPX(bytes.NewReader(exampleGPX))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct {
+		kind     string
+		name     string
+		placeKey string
+		time     string
+	}{
+		{KindWaypoint, "SF City Hall", "@5vg-7gq-tvz", ""},
+		{KindWaypoint, "Ferry Building", "@5vg-7gt-qzz", "2022-08-01T09:00:00Z"},
+		{KindRoutePoint, "Market Street", "@5vg-7gq-tvz", ""},
+		{KindRoutePoint, "Market Street", "@5vg-7gt-qzz", ""},
+		{KindTrackPoint, "Morning walk", "@5vg-7gq-tvz", "2022-08-01T08:00:00Z"},
+		{KindTrackPoint, "Morning walk", "@5vg-7gq-tvz", "2022-08-01T08:05:00Z"},
+		{KindTrackPoint, "Morning walk", "@5vg-7gt-qzz", "2022-08-01T08:30:00Z"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("ReadGPX() got %d points, want %d", len(got), len(want))
+	}
+	for i, w := range want {
+		p := got[i]
+		if p.Kind != w.kind || p.Name != w.name || p.PlaceKey != w.placeKey {
+			t.Errorf("ReadGPX() point %d got = %+v", i, p)
+		}
+		if w.time == "" && !p.Time.IsZero() {
+			t.Errorf("ReadGPX() point %d got time %v", i, p.Time)
+		}
+		if w.time != "" && p.Time.Format(time.RFC3339) != w.time {
+			t.Errorf("ReadGPX() point %d got time %v, want %v", i, p.Time, w.time)
+		}
+	}
+	if _, err := c.ReadGPX(strings.NewReader("<gpx><wpt lat=\"91\" lon=\"0\"/></gpx>")); err == nil {
+		t.Error("ReadGPX() expected error")
+	}
+}
+
+func TestH3_ReadNMEA(t *testing.T) {
+	c := NewH3()
+	defer c.Close()
+	got, err := c.ReadNMEA(bytes.NewReader(exampleNMEA))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"0001-01-01T23:59:58Z",
+		"2022-07-31T23:59:59Z",
+		"2022-08-01T00:00:01Z",
+		"2022-08-01T00:00:10Z",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("ReadNMEA() got %d points, want %d: %+v", len(got), len(want), got)
+	}
+	for i, w := range want {
+		if got[i].Time.Format(time.RFC3339) != w {
+			t.Errorf("ReadNMEA() point %d got time %v, want %v", i, got[i].Time, w)
+		}
+		if got[i].Kind != KindFix {
+			t.Errorf("ReadNMEA() point %d got kind %v", i, got[i].Kind)
+		}
+	}
+	if !almostEqual(got[1].Lat, 37.779273) || !almostEqual(got[1].Lng, -122.419262) || got[1].PlaceKey != "@5vg-7gq-tvz" {
+		t.Errorf("ReadNMEA() got = %+v", got[1])
+	}
+	if got[3].Lat < 37.795 || got[3].Lng < -122.394 {
+		t.Errorf("ReadNMEA() got = %+v", got[3])
+	}
+}
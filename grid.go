@@ -0,0 +1,78 @@
+package placekey
+
+import (
+	"errors"
+
+	"github.com/diegosz/placekey-go/internal/h3"
+)
+
+var ErrTooFarApart = errors.New("placekeys too far apart")
+var ErrPentagonDistortion = errors.New("placekeys straddle pentagon distortion")
+var ErrGridFailed = errors.New("grid computation failed")
+
+// GridDistance returns the number of resolution 10 hexagon steps between two
+// PlaceKeys.
+//
+// It returns ErrTooFarApart when the cells lie on base cells that are not
+// neighbors, and ErrPentagonDistortion when the path between them crosses the
+// distortion around a pentagon. It returns ErrGridFailed when the distance
+// cannot be computed for another reason.
+func (c *H3) GridDistance(placeKey1, placeKey2 string) (int, error) {
+	x1, x2, err := c.gridIndexes(placeKey1, placeKey2)
+	if err != nil {
+		return 0, err
+	}
+	d := c.h3.Distance(x1, x2)
+	if d < 0 {
+		return 0, gridError(c.h3.LocalIjkStatus(x1, x2))
+	}
+	return d, nil
+}
+
+// GridPath returns the line of PlaceKeys connecting two PlaceKeys, both
+// included. Consecutive PlaceKeys in the path are neighbors.
+//
+// It fails under the same conditions as GridDistance.
+func (c *H3) GridPath(placeKey1, placeKey2 string) ([]string, error) {
+	x1, x2, err := c.gridIndexes(placeKey1, placeKey2)
+	if err != nil {
+		return nil, err
+	}
+	line := c.h3.Line(x1, x2)
+	if line == nil {
+		return nil, gridError(c.h3.LocalIjkStatus(x1, x2))
+	}
+	path := make([]string, 0, len(line))
+	for _, x := range line {
+		path = append(path, encodeH3Int(uint64(x)))
+	}
+	return path, nil
+}
+
+func (c *H3) gridIndexes(placeKey1, placeKey2 string) (h3.Index, h3.Index, error) {
+	x1, err := ToH3Index(placeKey1)
+	if err != nil {
+		return 0, 0, err
+	}
+	x2, err := ToH3Index(placeKey2)
+	if err != nil {
+		return 0, 0, err
+	}
+	return x1, x2, nil
+}
+
+// gridError explains a failed grid computation from the status of the local
+// IJK coordinates of its indexes. A computation may fail even though the
+// coordinates could be computed, which gives ErrGridFailed.
+func gridError(status int) error {
+	switch {
+	case status == 1:
+		return ErrInvalidResolution
+	case status == 2:
+		return ErrTooFarApart
+	case status >= 3:
+		return ErrPentagonDistortion
+	default:
+		return ErrGridFailed
+	}
+}
@@ -0,0 +1,101 @@
+package placekey
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestH3_GridDistance(t *testing.T) {
+	tests := []struct {
+		name      string
+		placeKey1 string
+		placeKey2 string
+		want      int
+		wantErr   error
+	}{
+		{
+			name:      "same placekey",
+			placeKey1: "@5vg-7gq-tvz",
+			placeKey2: "@5vg-7gq-tvz",
+			want:      0,
+		},
+		{
+			name:      "SF City Hall to Ferry Building",
+			placeKey1: "@5vg-7gq-tvz",
+			placeKey2: "zzw-22y@5vg-7gt-qzz",
+			want:      24,
+		},
+		{
+			name:      "too far apart",
+			placeKey1: "@5vg-7gq-tvz",
+			placeKey2: "@nxd-g5g-xyv",
+			wantErr:   ErrTooFarApart,
+		},
+		{
+			name:      "around a pentagon",
+			placeKey1: "@nv5-7cf-pd9",
+			placeKey2: "@nv5-7cm-7yv",
+			wantErr:   ErrPentagonDistortion,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewH3()
+			defer c.Close()
+			got, err := c.GridDistance(tt.placeKey1, tt.placeKey2)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("GridDistance() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("GridDistance() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestH3_GridPath(t *testing.T) {
+	c := NewH3()
+	defer c.Close()
+	got, err := c.GridPath("@5vg-7gq-tvz", "@5vg-7gt-qzz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 25 {
+		t.Fatalf("GridPath() got %d placekeys, want 25", len(got))
+	}
+	if got[0] != "@5vg-7gq-tvz" || got[len(got)-1] != "@5vg-7gt-qzz" {
+		t.Errorf("GridPath() got ends %s and %s", got[0], got[len(got)-1])
+	}
+	for i := 1; i < len(got); i++ {
+		d, err := c.GridDistance(got[i-1], got[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if d != 1 {
+			t.Errorf("GridPath() step %d has distance %d", i, d)
+		}
+	}
+	if _, err := c.GridPath("@5vg-7gq-tvz", "@nxd-g5g-xyv"); !errors.Is(err, ErrTooFarApart) {
+		t.Errorf("GridPath() error = %v, wantErr %v", err, ErrTooFarApart)
+	}
+}
+
+func TestGridError(t *testing.T) {
+	tests := []struct {
+		status int
+		want   error
+	}{
+		{0, ErrGridFailed},
+		{1, ErrInvalidResolution},
+		{2, ErrTooFarApart},
+		{3, ErrPentagonDistortion},
+		{5, ErrPentagonDistortion},
+		{-1, ErrGridFailed},
+	}
+	for _, tt := range tests {
+		if got := gridError(tt.status); !errors.Is(got, tt.want) {
+			t.Errorf("gridError(%d) got = %v, want %v", tt.status, got, tt.want)
+		}
+	}
+}
@@ -13,6 +13,7 @@ const (
 )
 
 var ErrInvalidLatLngRange = errors.New("invalid lat/lng range")
+var ErrInvalidCell = errors.New("invalid cell")
 
 type H3 struct {
 	h3 *h3.H3
@@ -94,12 +95,47 @@ func geoDistance(lat1, lng1, lat2, lng2 float64) float64 {
 	return 2.0 * earthRadius * math.Asin(radical) * 1000
 }
 
+// geoDestination returns the (latitude, longitude) reached by travelling a
+// distance in meters from a coordinate along an initial bearing in degrees
+// clockwise from north.
+func geoDestination(lat, lng, bearing, distance float64) (float64, float64) {
+	rLat := radians(lat)
+	rLng := radians(lng)
+	rBearing := radians(bearing)
+	d := distance / (earthRadius * 1000)
+	lat2 := math.Asin(math.Sin(rLat)*math.Cos(d) + math.Cos(rLat)*math.Sin(d)*math.Cos(rBearing))
+	lng2 := rLng + math.Atan2(math.Sin(rBearing)*math.Sin(d)*math.Cos(rLat), math.Cos(d)-math.Sin(rLat)*math.Sin(lat2))
+	return degrees(lat2), normalizeLng(degrees(lng2))
+}
+
+// geoBearing returns the initial bearing in degrees clockwise from north of the
+// great circle path between two (latitude, longitude) coordinates.
+func geoBearing(lat1, lng1, lat2, lng2 float64) float64 {
+	rLat1 := radians(lat1)
+	rLat2 := radians(lat2)
+	dLng := radians(lng2 - lng1)
+	y := math.Sin(dLng) * math.Cos(rLat2)
+	x := math.Cos(rLat1)*math.Sin(rLat2) - math.Sin(rLat1)*math.Cos(rLat2)*math.Cos(dLng)
+	return degrees(math.Atan2(y, x))
+}
+
+// normalizeLng wraps a longitude in degrees into [-180, 180].
+func normalizeLng(lng float64) float64 {
+	for lng > 180 {
+		lng -= 360
+	}
+	for lng < -180 {
+		lng += 360
+	}
+	return lng
+}
+
 // radians converts degrees to radians
 func radians(degrees float64) float64 {
 	return degrees * math.Pi / 180
 }
 
 // degrees converts radians to degrees
-func degrees(radians float64) float64 { //nolint:deadcode,unused
+func degrees(radians float64) float64 {
 	return radians / math.Pi * 180
 }
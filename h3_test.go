@@ -238,52 +238,46 @@ func TestH3_ToGeoIssues(t *testing.T) {
 	}
 }
 
-// func TestH3_ToGeoBoundary(t *testing.T) {
-// 	tests := []struct {
-// 		name    string
-// 		h3Index string
-// 		want    [][]float64
-// 		wantErr bool
-// 	}{
-// 		{
-// 			name:    "8a2a1072b59ffff",
-// 			h3Index: "8a2a1072b59ffff", // "@627-wc5-z2k" // 622236750694711295
-// 			want: [][]float64{
-// 				{40.6900586009536, -74.04415176176158},
-// 				{40.689907694525196, -74.04506179239633},
-// 				{40.689270936043556, -74.04534141750702},
-// 				{40.688785090724046, -74.04471103053613},
-// 				{40.68893599264273, -74.04380102076256},
-// 				{40.689572744390546, -74.04352137709905},
-// 			},
-// 			wantErr: false,
-// 		},
-// 	}
-// 	for _, tt := range tests {
-// 		t.Run(tt.name, func(t *testing.T) {
-// 			c := NewH3()
-// 			defer c.Close()
-// 			pk, err := FromH3String(tt.h3Index)
-// 			if err != nil {
-// 				t.Fatal(err)
-// 			}
-// 			got, err := c.ToGeoBoundary(pk)
-// 			if (err != nil) != tt.wantErr {
-// 				t.Errorf("ToGeoBoundary() error = %v, wantErr %v", err, tt.wantErr)
-// 				return
-// 			}
-// 			if !reflect.DeepEqual(got, tt.want) {
-// 				t.Errorf("ToGeoBoundary() got = %v, want %v", got, tt.want)
-// 			}
-// 		})
-// 	}
-// }
-
-// FIXME: The above TestH3_ToGeoBoundary test is not working as expected.
-// It works fine in debug mode, but fails to run in testing.
-// ToGeoBoundary returns empty. Something smells...
-// The example test ExampleH3_ToGeoBoundary below is a working fine.
-// Also running in an executable binary works fine, at least for the moment...
+func TestH3_ToGeoBoundary(t *testing.T) {
+	tests := []struct {
+		name    string
+		h3Index string
+		want    [][]float64
+		wantErr bool
+	}{
+		{
+			name:    "8a2a1072b59ffff",
+			h3Index: "8a2a1072b59ffff", // "@627-wc5-z2k" // 622236750694711295
+			want: [][]float64{
+				{40.6900586009536, -74.04415176176158},
+				{40.689907694525196, -74.04506179239633},
+				{40.689270936043556, -74.04534141750702},
+				{40.688785090724046, -74.04471103053613},
+				{40.68893599264273, -74.04380102076256},
+				{40.689572744390546, -74.04352137709905},
+			},
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewH3()
+			defer c.Close()
+			pk, err := FromH3String(tt.h3Index)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := c.ToGeoBoundary(pk)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ToGeoBoundary() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ToGeoBoundary() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
 
 func ExampleH3_ToGeoBoundary() {
 	c := NewH3()
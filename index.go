@@ -0,0 +1,235 @@
+package placekey
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/diegosz/placekey-go/internal/h3"
+)
+
+var ErrInvalidK = errors.New("invalid k")
+
+// Item is a value stored in an Index, together with its PlaceKey and, in query
+// results, the distance in meters between the query point and the center of
+// the PlaceKey.
+type Item[T any] struct {
+	PlaceKey string
+	Value    T
+	Distance float64
+}
+
+// Index is an in-memory spatial index of values stored under PlaceKeys.
+//
+// An Index is safe for concurrent readers with a single writer. Queries that
+// need H3 computations take the H3 context of the calling goroutine, as an H3
+// context must not be shared between goroutines.
+type Index[T any] struct {
+	mu    sync.RWMutex
+	cells map[h3.Index][]Item[T]
+	size  int
+}
+
+// NewIndex returns an empty Index.
+func NewIndex[T any]() *Index[T] {
+	return &Index[T]{cells: map[h3.Index][]Item[T]{}}
+}
+
+// Len returns the number of values in the index.
+func (idx *Index[T]) Len() int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return idx.size
+}
+
+// Insert stores a value under a PlaceKey. Several values can be stored under
+// the same PlaceKey.
+func (idx *Index[T]) Insert(placeKey string, value T) error {
+	if !FormatIsValid(placeKey) {
+		return ErrInvalidFormat
+	}
+	x, err := ToH3Index(placeKey)
+	if err != nil {
+		return err
+	}
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.cells[x] = append(idx.cells[x], Item[T]{PlaceKey: placeKey, Value: value})
+	idx.size++
+	return nil
+}
+
+// Delete removes every value stored in the cell of a PlaceKey and returns the
+// number of values removed.
+func (idx *Index[T]) Delete(placeKey string) (int, error) {
+	return idx.DeleteFunc(placeKey, func(T) bool { return true })
+}
+
+// DeleteFunc removes the values stored in the cell of a PlaceKey for which del
+// returns true, and returns the number of values removed.
+func (idx *Index[T]) DeleteFunc(placeKey string, del func(T) bool) (int, error) {
+	x, err := ToH3Index(placeKey)
+	if err != nil {
+		return 0, err
+	}
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	items := idx.cells[x]
+	kept := items[:0]
+	for _, item := range items {
+		if !del(item.Value) {
+			kept = append(kept, item)
+		}
+	}
+	removed := len(items) - len(kept)
+	for i := len(kept); i < len(items); i++ {
+		items[i] = Item[T]{}
+	}
+	if len(kept) == 0 {
+		delete(idx.cells, x)
+	} else {
+		idx.cells[x] = kept
+	}
+	idx.size -= removed
+	return removed, nil
+}
+
+// Get returns the values stored in the cell of a PlaceKey, whatever the what
+// part of the PlaceKeys.
+func (idx *Index[T]) Get(placeKey string) ([]Item[T], error) {
+	x, err := ToH3Index(placeKey)
+	if err != nil {
+		return nil, err
+	}
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return append([]Item[T](nil), idx.cells[x]...), nil
+}
+
+// KNearest returns the k values nearest to a (latitude, longitude), sorted by
+// distance. Fewer values are returned when the index holds less than k values.
+func (idx *Index[T]) KNearest(c *H3, lat, lng float64, k int) ([]Item[T], error) {
+	if k < 0 {
+		return nil, ErrInvalidK
+	}
+	if k == 0 {
+		return []Item[T]{}, nil
+	}
+	var out []Item[T]
+	err := idx.search(c, lat, lng, func(items []Item[T], nearest float64) bool {
+		out = items
+		return len(out) < k || nearest <= out[k-1].Distance
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(out) > k {
+		out = out[:k]
+	}
+	return out, nil
+}
+
+// WithinRadius returns the values whose PlaceKey center is within a radius in
+// meters of a (latitude, longitude), sorted by distance.
+func (idx *Index[T]) WithinRadius(c *H3, lat, lng, meters float64) ([]Item[T], error) {
+	if meters < 0 || math.IsNaN(meters) {
+		return nil, ErrInvalidRadius
+	}
+	var out []Item[T]
+	err := idx.search(c, lat, lng, func(items []Item[T], nearest float64) bool {
+		out = items
+		return nearest <= meters
+	})
+	if err != nil {
+		return nil, err
+	}
+	n := sort.Search(len(out), func(i int) bool { return out[i].Distance > meters })
+	return out[:n], nil
+}
+
+// search visits the rings of cells around a (latitude, longitude) in order,
+// collecting the values found. After each ring, more is called with the values
+// collected so far, sorted by distance, and the boundary distance of the
+// nearest cell not visited yet; the search stops when more returns false or
+// when every value has been visited.
+//
+// As each ring surrounds the previous ones, no cell beyond a ring can be nearer
+// than the nearest cell of that ring.
+func (idx *Index[T]) search(c *H3, lat, lng float64, more func(items []Item[T], nearest float64) bool) error {
+	center, err := c.FromGeo(lat, lng)
+	if err != nil {
+		return err
+	}
+	origin, err := ToH3Index(center)
+	if err != nil {
+		return err
+	}
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	items := []Item[T]{}
+	collect := func(x h3.Index) {
+		cLat, cLng, _ := c.cellGeo(x)
+		d := geoDistance(lat, lng, cLat, cLng)
+		for _, item := range idx.cells[x] {
+			item.Distance = d
+			items = append(items, item)
+		}
+	}
+	visited := map[h3.Index]bool{}
+	found := 0 // visited cells holding values
+	for k := 0; len(items) < idx.size; k++ {
+		ring := c.ring(origin, k, visited)
+		if len(ring) > len(idx.cells)-found {
+			// the remaining cells are fewer than the cells of the ring, visit
+			// them all at once
+			for x := range idx.cells {
+				if !visited[x] {
+					collect(x)
+				}
+			}
+			break
+		}
+		nearest := math.Inf(1)
+		for _, x := range ring {
+			visited[x] = true
+			if _, ok := idx.cells[x]; ok {
+				found++
+			}
+			_, _, boundary := c.cellGeo(x)
+			nearest = math.Min(nearest, boundaryDistance(lat, lng, boundary))
+			collect(x)
+		}
+		sortItems(items)
+		if !more(items, nearest) {
+			return nil
+		}
+	}
+	sortItems(items)
+	more(items, math.Inf(1))
+	return nil
+}
+
+// ring returns the cells exactly k grid steps from origin, excluding the cells
+// already visited.
+func (c *H3) ring(origin h3.Index, k int, visited map[h3.Index]bool) []h3.Index {
+	ring := c.h3.HexRing(origin, k)
+	if ring == nil {
+		// around pentagons the hollow ring is not defined, fall back to the
+		// filled disk minus the cells already visited
+		ring = c.h3.KRing(origin, k)
+	}
+	out := ring[:0]
+	for _, x := range ring {
+		if !visited[x] {
+			out = append(out, x)
+		}
+	}
+	return out
+}
+
+func sortItems[T any](items []Item[T]) {
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].Distance < items[j].Distance
+	})
+}
@@ -0,0 +1,175 @@
+package placekey
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"sync"
+	"testing"
+)
+
+// newTestIndex returns an index of the cells along a grid of points around SF
+// City Hall, each value being the number of the point.
+func newTestIndex(t *testing.T, c *H3) (*Index[int], []string) {
+	t.Helper()
+	idx := NewIndex[int]()
+	placeKeys := []string{}
+	n := 0
+	for i := -10; i <= 10; i++ {
+		for j := -10; j <= 10; j++ {
+			pk, err := c.FromGeo(37.779274+float64(i)*0.003, -122.419262+float64(j)*0.004)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := idx.Insert(pk, n); err != nil {
+				t.Fatal(err)
+			}
+			placeKeys = append(placeKeys, pk)
+			n++
+		}
+	}
+	return idx, placeKeys
+}
+
+func TestIndex_InsertGetDelete(t *testing.T) {
+	idx := NewIndex[string]()
+	if err := idx.Insert("@5vg-7gq-tvz", "city hall"); err != nil {
+		t.Fatal(err)
+	}
+	if err := idx.Insert("zzw-22y@5vg-7gq-tvz", "cafe"); err != nil {
+		t.Fatal(err)
+	}
+	if err := idx.Insert("@5vg-7gt-qzz", "ferry building"); err != nil {
+		t.Fatal(err)
+	}
+	if err := idx.Insert("@abc", "invalid"); !errors.Is(err, ErrInvalidFormat) {
+		t.Errorf("Insert() error = %v, wantErr %v", err, ErrInvalidFormat)
+	}
+	if idx.Len() != 3 {
+		t.Errorf("Len() got = %d, want 3", idx.Len())
+	}
+	got, err := idx.Get("@5vg-7gq-tvz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0].Value != "city hall" || got[1].PlaceKey != "zzw-22y@5vg-7gq-tvz" {
+		t.Errorf("Get() got = %v", got)
+	}
+	n, err := idx.DeleteFunc("@5vg-7gq-tvz", func(v string) bool { return v == "cafe" })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 || idx.Len() != 2 {
+		t.Errorf("DeleteFunc() got = %d, len %d", n, idx.Len())
+	}
+	n, err = idx.Delete("@5vg-7gq-tvz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 || idx.Len() != 1 {
+		t.Errorf("Delete() got = %d, len %d", n, idx.Len())
+	}
+	got, err = idx.Get("@5vg-7gq-tvz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 0 {
+		t.Errorf("Get() after Delete() got = %v", got)
+	}
+}
+
+func TestIndex_KNearest(t *testing.T) {
+	c := NewH3()
+	defer c.Close()
+	idx, placeKeys := newTestIndex(t, c)
+	lat, lng := 37.78, -122.42
+	want := bruteForceDistances(t, c, placeKeys, lat, lng)
+	for _, k := range []int{0, 1, 5, 50, 1000} {
+		got, err := idx.KNearest(c, lat, lng, k)
+		if err != nil {
+			t.Fatal(err)
+		}
+		wantLen := k
+		if wantLen > len(want) {
+			wantLen = len(want)
+		}
+		if len(got) != wantLen {
+			t.Fatalf("KNearest(%d) got %d items", k, len(got))
+		}
+		for i, item := range got {
+			if !almostEqual(item.Distance, want[i]) {
+				t.Errorf("KNearest(%d) item %d distance = %v, want %v", k, i, item.Distance, want[i])
+			}
+		}
+	}
+	if _, err := idx.KNearest(c, lat, lng, -1); !errors.Is(err, ErrInvalidK) {
+		t.Errorf("KNearest() error = %v, wantErr %v", err, ErrInvalidK)
+	}
+}
+
+func TestIndex_WithinRadius(t *testing.T) {
+	c := NewH3()
+	defer c.Close()
+	idx, placeKeys := newTestIndex(t, c)
+	lat, lng := 37.78, -122.42
+	all := bruteForceDistances(t, c, placeKeys, lat, lng)
+	for _, meters := range []float64{0, 100, 500, 2000} {
+		got, err := idx.WithinRadius(c, lat, lng, meters)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := sort.Search(len(all), func(i int) bool { return all[i] > meters })
+		if len(got) != want {
+			t.Errorf("WithinRadius(%v) got %d items, want %d", meters, len(got), want)
+		}
+	}
+}
+
+func TestIndex_ConcurrentReaders(t *testing.T) {
+	c := NewH3()
+	defer c.Close()
+	idx, _ := newTestIndex(t, c)
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c := NewH3()
+			defer c.Close()
+			for j := 0; j < 10; j++ {
+				if _, err := idx.KNearest(c, 37.78, -122.42, 10); err != nil {
+					t.Error(err)
+				}
+			}
+		}()
+	}
+	for i := 0; i < 10; i++ {
+		pk, err := c.FromGeo(37.7, -122.4+float64(i)*0.01)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := idx.Insert(pk, -i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wg.Wait()
+}
+
+func bruteForceDistances(t *testing.T, c *H3, placeKeys []string, lat, lng float64) []float64 {
+	t.Helper()
+	ds := make([]float64, 0, len(placeKeys))
+	for _, pk := range placeKeys {
+		pLat, pLng, err := c.ToGeo(pk)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ds = append(ds, geoDistance(lat, lng, pLat, pLng))
+	}
+	sort.Float64s(ds)
+	for i := range ds {
+		if math.IsNaN(ds[i]) {
+			t.Fatal("NaN distance")
+		}
+	}
+	return ds
+}
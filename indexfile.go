@@ -0,0 +1,377 @@
+//nolint:gomnd
+package placekey
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"syscall"
+)
+
+// Index file format
+//
+// An index file stores payloads under PlaceKeys, sorted by the 52 bits short
+// integer the where part of a PlaceKey encodes (see shortenH3Int). All integers
+// are little endian and every section starts at an offset multiple of 8, so the
+// file can be memory mapped and read in place.
+//
+//	offset  size       field
+//	0       8          magic "PKINDEX\x00"
+//	8       4          version, currently 1
+//	12      4          flags, reserved, 0
+//	16      8          count, number of entries
+//	24      8          keys offset
+//	32      8          offsets offset
+//	40      8          payload offset
+//	48      8          payload size
+//	56      4          CRC-32C of the keys section
+//	60      4          CRC-32C of the offsets section
+//	64      4          CRC-32C of the payload section
+//	68      4          CRC-32C of bytes 0 to 67 of the header
+//	72      8*count    keys section, sorted short integers
+//	...     8*count+8  offsets section, start of each payload relative to the
+//	                   payload section, followed by the payload size
+//	...     size       payload section
+//
+// Entries sharing a PlaceKey are stored next to each other, in the order they
+// were added. The what part of the PlaceKeys is not stored; keep it in the
+// payload when needed.
+
+const (
+	indexFileMagic      string = "PKINDEX\x00"
+	indexFileVersion    uint32 = 1
+	indexFileHeaderSize int    = 72
+	whereCodeLength     int    = 9
+)
+
+var ErrInvalidIndexFile = errors.New("invalid index file")
+var ErrUnsupportedVersion = errors.New("unsupported index file version")
+var ErrChecksumMismatch = errors.New("index file checksum mismatch")
+var ErrInvalidPrefix = errors.New("invalid prefix")
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+type indexFileEntry struct {
+	key     uint64
+	payload []byte
+}
+
+// IndexFileBuilder collects payloads under PlaceKeys and writes them as an
+// index file.
+type IndexFileBuilder struct {
+	entries []indexFileEntry
+}
+
+// NewIndexFileBuilder returns an empty IndexFileBuilder.
+func NewIndexFileBuilder() *IndexFileBuilder {
+	return &IndexFileBuilder{}
+}
+
+// Add adds a payload under a PlaceKey. The payload is not copied.
+func (b *IndexFileBuilder) Add(placeKey string, payload []byte) error {
+	key, err := indexFileKey(placeKey)
+	if err != nil {
+		return err
+	}
+	b.entries = append(b.entries, indexFileEntry{key: key, payload: payload})
+	return nil
+}
+
+// WriteTo writes the index file to w.
+func (b *IndexFileBuilder) WriteTo(w io.Writer) (int64, error) {
+	sort.SliceStable(b.entries, func(i, j int) bool {
+		return b.entries[i].key < b.entries[j].key
+	})
+	count := uint64(len(b.entries))
+	keys := make([]byte, 8*count)
+	offsets := make([]byte, 8*count+8)
+	keysCRC := crc32.New(castagnoli)
+	payloadCRC := crc32.New(castagnoli)
+	var payloadSize uint64
+	for i, e := range b.entries {
+		binary.LittleEndian.PutUint64(keys[8*i:], e.key)
+		binary.LittleEndian.PutUint64(offsets[8*i:], payloadSize)
+		payloadSize += uint64(len(e.payload))
+		_, _ = payloadCRC.Write(e.payload)
+	}
+	binary.LittleEndian.PutUint64(offsets[8*count:], payloadSize)
+	_, _ = keysCRC.Write(keys)
+
+	header := make([]byte, indexFileHeaderSize)
+	copy(header, indexFileMagic)
+	binary.LittleEndian.PutUint32(header[8:], indexFileVersion)
+	binary.LittleEndian.PutUint64(header[16:], count)
+	keysOffset := uint64(indexFileHeaderSize)
+	offsetsOffset := keysOffset + uint64(len(keys))
+	payloadOffset := offsetsOffset + uint64(len(offsets))
+	binary.LittleEndian.PutUint64(header[24:], keysOffset)
+	binary.LittleEndian.PutUint64(header[32:], offsetsOffset)
+	binary.LittleEndian.PutUint64(header[40:], payloadOffset)
+	binary.LittleEndian.PutUint64(header[48:], payloadSize)
+	binary.LittleEndian.PutUint32(header[56:], keysCRC.Sum32())
+	binary.LittleEndian.PutUint32(header[60:], crc32.Checksum(offsets, castagnoli))
+	binary.LittleEndian.PutUint32(header[64:], payloadCRC.Sum32())
+	binary.LittleEndian.PutUint32(header[68:], crc32.Checksum(header[:68], castagnoli))
+
+	bw := bufio.NewWriter(w)
+	var n int64
+	for _, section := range [][]byte{header, keys, offsets} {
+		m, err := bw.Write(section)
+		n += int64(m)
+		if err != nil {
+			return n, err
+		}
+	}
+	for _, e := range b.entries {
+		m, err := bw.Write(e.payload)
+		n += int64(m)
+		if err != nil {
+			return n, err
+		}
+	}
+	return n, bw.Flush()
+}
+
+// WriteFile writes the index file to the named file, replacing it atomically.
+func (b *IndexFileBuilder) WriteFile(name string) error {
+	tmp := name + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if _, err := b.WriteTo(f); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, name)
+}
+
+// IndexFile is a read-only, memory mapped index file.
+//
+// Payloads returned by an IndexFile point into the mapped file and must not be
+// modified or used after Close. An IndexFile is safe for concurrent use.
+type IndexFile struct {
+	data       []byte
+	count      int
+	keys       []byte
+	offsets    []byte
+	payload    []byte
+	payloadCRC uint32
+	release    func() error
+}
+
+// OpenIndexFile opens an index file. The header and the keys and offsets
+// sections are checked against their checksums; use Verify to also check the
+// payload section.
+func OpenIndexFile(name string) (*IndexFile, error) {
+	data, release, err := mapFile(name)
+	if err != nil {
+		return nil, err
+	}
+	f, err := newIndexFile(data)
+	if err != nil {
+		_ = release()
+		return nil, err
+	}
+	f.release = release
+	return f, nil
+}
+
+// mapFile memory maps the named file read-only.
+func mapFile(name string) ([]byte, func() error, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, nil, err
+	}
+	if fi.Size() < int64(indexFileHeaderSize) {
+		return nil, nil, ErrInvalidIndexFile
+	}
+	data, err := syscall.Mmap(int(f.Fd()), 0, int(fi.Size()), syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, func() error { return syscall.Munmap(data) }, nil
+}
+
+func newIndexFile(data []byte) (*IndexFile, error) {
+	if len(data) < indexFileHeaderSize || string(data[:8]) != indexFileMagic {
+		return nil, ErrInvalidIndexFile
+	}
+	header := data[:indexFileHeaderSize]
+	if crc32.Checksum(header[:68], castagnoli) != binary.LittleEndian.Uint32(header[68:]) {
+		return nil, ErrChecksumMismatch
+	}
+	if binary.LittleEndian.Uint32(header[8:]) != indexFileVersion {
+		return nil, ErrUnsupportedVersion
+	}
+	count := binary.LittleEndian.Uint64(header[16:])
+	keysOffset := binary.LittleEndian.Uint64(header[24:])
+	offsetsOffset := binary.LittleEndian.Uint64(header[32:])
+	payloadOffset := binary.LittleEndian.Uint64(header[40:])
+	payloadSize := binary.LittleEndian.Uint64(header[48:])
+	size := uint64(len(data))
+	if count > size/16 ||
+		keysOffset%8 != 0 || keysOffset > size || 8*count > size-keysOffset ||
+		offsetsOffset%8 != 0 || offsetsOffset > size || 8*count+8 > size-offsetsOffset ||
+		payloadOffset > size || payloadSize > size-payloadOffset {
+		return nil, ErrInvalidIndexFile
+	}
+	f := &IndexFile{
+		data:       data,
+		count:      int(count),
+		keys:       data[keysOffset : keysOffset+8*count],
+		offsets:    data[offsetsOffset : offsetsOffset+8*count+8],
+		payload:    data[payloadOffset : payloadOffset+payloadSize],
+		payloadCRC: binary.LittleEndian.Uint32(header[64:]),
+	}
+	if crc32.Checksum(f.keys, castagnoli) != binary.LittleEndian.Uint32(header[56:]) ||
+		crc32.Checksum(f.offsets, castagnoli) != binary.LittleEndian.Uint32(header[60:]) {
+		return nil, ErrChecksumMismatch
+	}
+	// keys are sorted, entries sharing a PlaceKey being next to each other,
+	// and payloads follow each other within the payload section
+	for i := 0; i < f.count; i++ {
+		start := binary.LittleEndian.Uint64(f.offsets[8*i:])
+		end := binary.LittleEndian.Uint64(f.offsets[8*i+8:])
+		if start > end || end > payloadSize || (i > 0 && f.key(i-1) > f.key(i)) {
+			return nil, ErrInvalidIndexFile
+		}
+	}
+	return f, nil
+}
+
+// Close releases the file. Payloads returned before must not be used after.
+func (f *IndexFile) Close() error {
+	if f.release == nil {
+		return nil
+	}
+	err := f.release()
+	f.release = nil
+	f.data, f.keys, f.offsets, f.payload = nil, nil, nil, nil
+	return err
+}
+
+// Verify checks the payload section against its checksum.
+func (f *IndexFile) Verify() error {
+	if crc32.Checksum(f.payload, castagnoli) != f.payloadCRC {
+		return ErrChecksumMismatch
+	}
+	return nil
+}
+
+// Len returns the number of entries in the file.
+func (f *IndexFile) Len() int {
+	return f.count
+}
+
+// Lookup returns the payloads stored under the where part of a PlaceKey.
+func (f *IndexFile) Lookup(placeKey string) ([][]byte, error) {
+	key, err := indexFileKey(placeKey)
+	if err != nil {
+		return nil, err
+	}
+	payloads := [][]byte{}
+	err = f.scan(key, key, func(_ string, payload []byte) bool {
+		payloads = append(payloads, payload)
+		return true
+	})
+	return payloads, err
+}
+
+// ScanPrefix calls fn, in key order, for each entry whose PlaceKey where part
+// starts with prefix, until fn returns false. The prefix may include the "@"
+// and "-" separators, as in "@5vg-7g".
+func (f *IndexFile) ScanPrefix(prefix string, fn func(placeKey string, payload []byte) bool) error {
+	from, to, err := prefixRange(prefix)
+	if err != nil {
+		return err
+	}
+	return f.scan(from, to, fn)
+}
+
+// ScanRange calls fn, in key order, for each entry whose PlaceKey lies between
+// from and to included, until fn returns false.
+func (f *IndexFile) ScanRange(from, to string, fn func(placeKey string, payload []byte) bool) error {
+	lo, err := indexFileKey(from)
+	if err != nil {
+		return err
+	}
+	hi, err := indexFileKey(to)
+	if err != nil {
+		return err
+	}
+	return f.scan(lo, hi, fn)
+}
+
+func (f *IndexFile) scan(lo, hi uint64, fn func(placeKey string, payload []byte) bool) error {
+	i := sort.Search(f.count, func(i int) bool { return f.key(i) >= lo })
+	for ; i < f.count; i++ {
+		key := f.key(i)
+		if key > hi {
+			break
+		}
+		start := binary.LittleEndian.Uint64(f.offsets[8*i:])
+		end := binary.LittleEndian.Uint64(f.offsets[8*i+8:])
+		if start > end || end > uint64(len(f.payload)) {
+			return ErrInvalidIndexFile
+		}
+		if !fn(encodeH3Int(unshortenH3Int(int64(key))), f.payload[start:end:end]) {
+			break
+		}
+	}
+	return nil
+}
+
+func (f *IndexFile) key(i int) uint64 {
+	return binary.LittleEndian.Uint64(f.keys[8*i:])
+}
+
+// indexFileKey returns the short integer of the where part of a PlaceKey.
+func indexFileKey(placeKey string) (uint64, error) {
+	if !FormatIsValid(placeKey) {
+		return 0, ErrInvalidFormat
+	}
+	x, err := ToH3Int(placeKey)
+	if err != nil {
+		return 0, err
+	}
+	return uint64(shortenH3Int(x)), nil
+}
+
+// prefixRange returns the range of short integers of the PlaceKeys whose where
+// part starts with prefix. Replacements made when cleaning a PlaceKey only
+// change the last character of a replaced sequence, so the prefix is decoded
+// as a whole before being turned into a range.
+func prefixRange(prefix string) (uint64, uint64, error) {
+	code := strings.ReplaceAll(strings.ReplaceAll(prefix, "@", ""), "-", "")
+	if len(code) > whereCodeLength {
+		return 0, 0, ErrInvalidPrefix
+	}
+	padding := len(code) - len(strings.TrimLeft(code, paddingChar))
+	dirty := dirtyString(code[padding:])
+	var v uint64
+	for _, r := range dirty {
+		d := strings.IndexRune(alphabet, r)
+		if d < 0 {
+			return 0, 0, ErrInvalidPrefix
+		}
+		v = v*uint64(alphabetLength) + uint64(d)
+	}
+	scale := uint64(power64(alphabetLength, whereCodeLength-len(code)))
+	return v * scale, (v+1)*scale - 1, nil
+}
@@ -0,0 +1,271 @@
+package placekey
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeTestIndexFile(t *testing.T, c *H3) (string, map[string][]string) {
+	t.Helper()
+	b := NewIndexFileBuilder()
+	want := map[string][]string{}
+	for i := -5; i <= 5; i++ {
+		for j := -5; j <= 5; j++ {
+			pk, err := c.FromGeo(37.779274+float64(i)*0.01, -122.419262+float64(j)*0.01)
+			if err != nil {
+				t.Fatal(err)
+			}
+			payload := pk + "#" + strings.Repeat("x", i+5)
+			if err := b.Add(pk, []byte(payload)); err != nil {
+				t.Fatal(err)
+			}
+			want[pk] = append(want[pk], payload)
+		}
+	}
+	if err := b.Add("zzw-22y@5vg-7gq-tvz", []byte("city hall")); err != nil {
+		t.Fatal(err)
+	}
+	want["@5vg-7gq-tvz"] = append(want["@5vg-7gq-tvz"], "city hall")
+	if err := b.Add("@abc", nil); !errors.Is(err, ErrInvalidFormat) {
+		t.Errorf("Add() error = %v, wantErr %v", err, ErrInvalidFormat)
+	}
+	name := filepath.Join(t.TempDir(), "index.pk")
+	if err := b.WriteFile(name); err != nil {
+		t.Fatal(err)
+	}
+	return name, want
+}
+
+func TestIndexFile_Lookup(t *testing.T) {
+	c := NewH3()
+	defer c.Close()
+	name, want := writeTestIndexFile(t, c)
+	f, err := OpenIndexFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if err := f.Verify(); err != nil {
+		t.Fatal(err)
+	}
+	if f.Len() != 122 {
+		t.Errorf("Len() got = %d, want 122", f.Len())
+	}
+	for pk, payloads := range want {
+		got, err := f.Lookup(pk)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != len(payloads) {
+			t.Fatalf("Lookup(%s) got %d payloads, want %d", pk, len(got), len(payloads))
+		}
+		for i := range got {
+			if string(got[i]) != payloads[i] {
+				t.Errorf("Lookup(%s) got = %s, want %s", pk, got[i], payloads[i])
+			}
+		}
+	}
+	got, err := f.Lookup("@5vg-7gt-qzz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 0 {
+		t.Errorf("Lookup() missing placekey got = %v", got)
+	}
+}
+
+func TestIndexFile_Scan(t *testing.T) {
+	c := NewH3()
+	defer c.Close()
+	name, want := writeTestIndexFile(t, c)
+	f, err := OpenIndexFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	for _, prefix := range []string{"@", "@5v", "@5vg", "@5vg-7g", "@5vg-7gq", "@5vg-7gq-tvz", "@5vb"} {
+		count := 0
+		for pk, payloads := range want {
+			if strings.HasPrefix(pk, prefix) {
+				count += len(payloads)
+			}
+		}
+		got := 0
+		err := f.ScanPrefix(prefix, func(pk string, payload []byte) bool {
+			if !strings.HasPrefix(pk, prefix) {
+				t.Errorf("ScanPrefix(%s) got = %s", prefix, pk)
+			}
+			if !bytes.HasPrefix(payload, []byte(pk)) && string(payload) != "city hall" {
+				t.Errorf("ScanPrefix(%s) got payload %s for %s", prefix, payload, pk)
+			}
+			got++
+			return true
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != count {
+			t.Errorf("ScanPrefix(%s) got %d entries, want %d", prefix, got, count)
+		}
+	}
+	got := 0
+	err = f.ScanRange("@5vg-7gq-tvz", "@5vg-7gq-tvz", func(string, []byte) bool {
+		got++
+		return true
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != len(want["@5vg-7gq-tvz"]) {
+		t.Errorf("ScanRange() got %d entries", got)
+	}
+	got = 0
+	err = f.ScanPrefix("@", func(string, []byte) bool {
+		got++
+		return got < 3
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != 3 {
+		t.Errorf("ScanPrefix() did not stop, got %d entries", got)
+	}
+	if err := f.ScanPrefix("@5vg-7gq-tvz-2", nil); !errors.Is(err, ErrInvalidPrefix) {
+		t.Errorf("ScanPrefix() error = %v, wantErr %v", err, ErrInvalidPrefix)
+	}
+}
+
+func TestIndexFile_Corruption(t *testing.T) {
+	c := NewH3()
+	defer c.Close()
+	name, _ := writeTestIndexFile(t, c)
+	data, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name     string
+		offset   int
+		wantErr  error
+		wantOpen bool
+	}{
+		{
+			name:    "magic",
+			offset:  0,
+			wantErr: ErrInvalidIndexFile,
+		},
+		{
+			name:    "header",
+			offset:  20,
+			wantErr: ErrChecksumMismatch,
+		},
+		{
+			name:    "keys",
+			offset:  indexFileHeaderSize + 3,
+			wantErr: ErrChecksumMismatch,
+		},
+		{
+			name:     "payload",
+			offset:   len(data) - 1,
+			wantErr:  ErrChecksumMismatch,
+			wantOpen: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			corrupted := append([]byte(nil), data...)
+			corrupted[tt.offset] ^= 0xff
+			f, err := newIndexFile(corrupted)
+			if tt.wantOpen {
+				if err != nil {
+					t.Fatal(err)
+				}
+				err = f.Verify()
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestIndexFile_InvalidLayout(t *testing.T) {
+	c := NewH3()
+	defer c.Close()
+	name, _ := writeTestIndexFile(t, c)
+	data, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	count := binary.LittleEndian.Uint64(data[16:])
+	keysOffset := binary.LittleEndian.Uint64(data[24:])
+	offsetsOffset := binary.LittleEndian.Uint64(data[32:])
+	payloadSize := binary.LittleEndian.Uint64(data[48:])
+	tests := []struct {
+		name   string
+		modify func(data []byte)
+	}{
+		{
+			name: "keys offset overflowing",
+			modify: func(data []byte) {
+				binary.LittleEndian.PutUint64(data[24:], math.MaxUint64-8*count+1)
+			},
+		},
+		{
+			name: "offsets offset overflowing",
+			modify: func(data []byte) {
+				binary.LittleEndian.PutUint64(data[32:], math.MaxUint64-8*count-7)
+			},
+		},
+		{
+			name: "keys not aligned",
+			modify: func(data []byte) {
+				binary.LittleEndian.PutUint64(data[24:], keysOffset+4)
+			},
+		},
+		{
+			name: "keys not sorted",
+			modify: func(data []byte) {
+				keys := data[keysOffset:]
+				first := binary.LittleEndian.Uint64(keys)
+				binary.LittleEndian.PutUint64(keys, binary.LittleEndian.Uint64(keys[8*count-8:]))
+				binary.LittleEndian.PutUint64(keys[8*count-8:], first)
+			},
+		},
+		{
+			name: "offsets decreasing",
+			modify: func(data []byte) {
+				binary.LittleEndian.PutUint64(data[offsetsOffset+8:], payloadSize)
+			},
+		},
+		{
+			name: "offsets beyond payload",
+			modify: func(data []byte) {
+				binary.LittleEndian.PutUint64(data[offsetsOffset+8*count:], payloadSize+1)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			modified := append([]byte(nil), data...)
+			tt.modify(modified)
+			// refresh the checksums of the sections left in place so that
+			// only the layout is wrong
+			keys := modified[keysOffset : keysOffset+8*count]
+			offsets := modified[offsetsOffset : offsetsOffset+8*count+8]
+			binary.LittleEndian.PutUint32(modified[56:], crc32.Checksum(keys, castagnoli))
+			binary.LittleEndian.PutUint32(modified[60:], crc32.Checksum(offsets, castagnoli))
+			binary.LittleEndian.PutUint32(modified[68:], crc32.Checksum(modified[:68], castagnoli))
+			if _, err := newIndexFile(modified); !errors.Is(err, ErrInvalidIndexFile) {
+				t.Errorf("newIndexFile() error = %v, wantErr %v", err, ErrInvalidIndexFile)
+			}
+		})
+	}
+}
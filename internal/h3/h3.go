@@ -38,16 +38,20 @@ func (c *H3) Close() {
 }
 
 func (c *H3) FromGeo(geo GeoCoord, res int) Index {
-	cgeo := ch3.TGeoCoord{
+	p := c.calloc(1, int(unsafe.Sizeof(ch3.TGeoCoord{})))
+	defer c.free(p)
+	*(*ch3.TGeoCoord)(pointer(p)) = ch3.TGeoCoord{
 		Flat: deg2rad * geo.Latitude,
 		Flon: deg2rad * geo.Longitude,
 	}
-	return Index(ch3.XgeoToH3(c.TLS, uintptr(unsafe.Pointer(&cgeo)), int32(res)))
+	return Index(ch3.XgeoToH3(c.TLS, p, int32(res)))
 }
 
 func (c *H3) ToGeo(h Index) GeoCoord {
-	cg := ch3.TGeoCoord{}
-	ch3.Xh3ToGeo(c.TLS, ch3.TH3Index(h), uintptr(unsafe.Pointer(&cg)))
+	p := c.calloc(1, int(unsafe.Sizeof(ch3.TGeoCoord{})))
+	defer c.free(p)
+	ch3.Xh3ToGeo(c.TLS, ch3.TH3Index(h), p)
+	cg := (*ch3.TGeoCoord)(pointer(p))
 	g := GeoCoord{}
 	g.Latitude = rad2deg * cg.Flat
 	g.Longitude = rad2deg * cg.Flon
@@ -59,8 +63,10 @@ func (c *H3) ToString(h Index) string {
 }
 
 func (c *H3) ToGeoBoundary(h Index) []GeoCoord {
-	gb := ch3.TGeoBoundary{}
-	ch3.Xh3ToGeoBoundary(c.TLS, ch3.TH3Index(h), uintptr(unsafe.Pointer(&gb)))
+	p := c.calloc(1, int(unsafe.Sizeof(ch3.TGeoBoundary{})))
+	defer c.free(p)
+	ch3.Xh3ToGeoBoundary(c.TLS, ch3.TH3Index(h), p)
+	gb := (*ch3.TGeoBoundary)(pointer(p))
 	gs := make([]GeoCoord, 0, gb.FnumVerts)
 	for i := 0; i < int(gb.FnumVerts); i++ {
 		g := GeoCoord{}
@@ -76,3 +82,237 @@ func (c *H3) ToGeoBoundary(h Index) []GeoCoord {
 func (c *H3) IsValid(h Index) bool {
 	return ch3.Xh3IsValid(c.TLS, ch3.TH3Index(h)) == 1
 }
+
+// IsPentagon returns whether or not the index is a pentagon.
+func (c *H3) IsPentagon(h Index) bool {
+	return ch3.Xh3IsPentagon(c.TLS, ch3.TH3Index(h)) == 1
+}
+
+// BaseCell returns the resolution 0 base cell number of the index.
+func (c *H3) BaseCell(h Index) int {
+	return int(ch3.Xh3GetBaseCell(c.TLS, ch3.TH3Index(h)))
+}
+
+// Faces returns the icosahedron faces intersected by the index.
+func (c *H3) Faces(h Index) []int {
+	n := int(ch3.XmaxFaceCount(c.TLS, ch3.TH3Index(h)))
+	p := c.calloc(n, 4)
+	defer c.free(p)
+	ch3.Xh3GetFaces(c.TLS, ch3.TH3Index(h), p)
+	fs := []int{}
+	for _, f := range unsafe.Slice((*int32)(pointer(p)), n) {
+		if f >= 0 {
+			fs = append(fs, int(f))
+		}
+	}
+	return fs
+}
+
+// CellAreaM2 returns the exact area of the index in square meters.
+func (c *H3) CellAreaM2(h Index) float64 {
+	return ch3.XcellAreaM2(c.TLS, ch3.TH3Index(h))
+}
+
+// EdgeLengthsM returns the exact length in meters of each edge of the index.
+func (c *H3) EdgeLengthsM(h Index) []float64 {
+	p := c.calloc(6, indexSize)
+	defer c.free(p)
+	ch3.XgetH3UnidirectionalEdgesFromHexagon(c.TLS, ch3.TH3Index(h), p)
+	ls := []float64{}
+	for _, e := range readIndexes(p, 6) {
+		ls = append(ls, ch3.XexactEdgeLengthM(c.TLS, ch3.TH3Index(e)))
+	}
+	return ls
+}
+
+// ToParent returns the parent of the index at a coarser resolution.
+func (c *H3) ToParent(h Index, res int) Index {
+	return Index(ch3.Xh3ToParent(c.TLS, ch3.TH3Index(h), int32(res)))
+}
+
+// ToChildren returns the children of the index at a finer resolution.
+func (c *H3) ToChildren(h Index, res int) []Index {
+	n := int(ch3.XmaxH3ToChildrenSize(c.TLS, ch3.TH3Index(h), int32(res)))
+	p := c.calloc(n, indexSize)
+	defer c.free(p)
+	ch3.Xh3ToChildren(c.TLS, ch3.TH3Index(h), int32(res), p)
+	return readIndexes(p, n)
+}
+
+// KRing returns the indexes within k grid steps of origin, origin included.
+func (c *H3) KRing(origin Index, k int) []Index {
+	n := int(ch3.XmaxKringSize(c.TLS, int32(k)))
+	p := c.calloc(n, indexSize)
+	defer c.free(p)
+	ch3.XkRing(c.TLS, ch3.TH3Index(origin), int32(k), p)
+	return readIndexes(p, n)
+}
+
+// HexRing returns the hollow ring of indexes exactly k grid steps from origin,
+// or nil when the ring crosses a pentagon distortion.
+func (c *H3) HexRing(origin Index, k int) []Index {
+	n := 1
+	if k > 0 {
+		n = 6 * k
+	}
+	p := c.calloc(n, indexSize)
+	defer c.free(p)
+	if ch3.XhexRing(c.TLS, ch3.TH3Index(origin), int32(k), p) != 0 {
+		return nil
+	}
+	return readIndexes(p, n)
+}
+
+// Distance returns the grid distance in cells between two indexes, or -1 when
+// the distance cannot be computed (see LocalIjkStatus for the reason).
+func (c *H3) Distance(origin, h Index) int {
+	return int(ch3.Xh3Distance(c.TLS, ch3.TH3Index(origin), ch3.TH3Index(h)))
+}
+
+// LocalIjkStatus returns the status of unfolding h into the local IJK
+// coordinate space anchored by origin: 0 on success, 1 when the resolutions
+// differ, 2 when the base cells are not neighbors and 3 or greater when the
+// unfolding crosses a pentagon distortion.
+func (c *H3) LocalIjkStatus(origin, h Index) int {
+	p := c.calloc(1, int(unsafe.Sizeof(ch3.TCoordIJK{})))
+	defer c.free(p)
+	return int(ch3.Xh3ToLocalIjk(c.TLS, ch3.TH3Index(origin), ch3.TH3Index(h), p))
+}
+
+// Line returns the line of indexes from start to end, both included, or nil
+// when the line cannot be computed.
+func (c *H3) Line(start, end Index) []Index {
+	n := ch3.Xh3LineSize(c.TLS, ch3.TH3Index(start), ch3.TH3Index(end))
+	if n < 0 {
+		return nil
+	}
+	p := c.calloc(int(n), indexSize)
+	defer c.free(p)
+	if ch3.Xh3Line(c.TLS, ch3.TH3Index(start), ch3.TH3Index(end), p) != 0 {
+		return nil
+	}
+	return readIndexes(p, int(n))
+}
+
+// SetToMultiPolygon dissolves a set of unique indexes of the same resolution
+// into the polygons outlining them. The first loop of each polygon is its outer
+// boundary in counter-clockwise order, and the remaining loops are its holes.
+func (c *H3) SetToMultiPolygon(hs []Index) []GeoPolygon {
+	if len(hs) == 0 {
+		return nil
+	}
+	set := c.calloc(len(hs), indexSize)
+	defer c.free(set)
+	copy(unsafe.Slice((*Index)(pointer(set)), len(hs)), hs)
+	out := c.calloc(1, int(unsafe.Sizeof(ch3.TLinkedGeoPolygon{})))
+	defer c.free(out)
+	ch3.Xh3SetToLinkedGeo(c.TLS, set, int32(len(hs)), out)
+	defer ch3.XdestroyLinkedPolygon(c.TLS, out)
+	polygons := []GeoPolygon{}
+	for p := out; p != 0; p = (*ch3.TLinkedGeoPolygon)(pointer(p)).Fnext {
+		polygon := GeoPolygon{}
+		for l := (*ch3.TLinkedGeoPolygon)(pointer(p)).Ffirst; l != 0; l = (*ch3.TLinkedGeoLoop)(pointer(l)).Fnext {
+			loop := []GeoCoord{}
+			for v := (*ch3.TLinkedGeoLoop)(pointer(l)).Ffirst; v != 0; v = (*ch3.TLinkedGeoCoord)(pointer(v)).Fnext {
+				vertex := (*ch3.TLinkedGeoCoord)(pointer(v)).Fvertex
+				loop = append(loop, GeoCoord{Latitude: rad2deg * vertex.Flat, Longitude: rad2deg * vertex.Flon})
+			}
+			if polygon.Geofence == nil {
+				polygon.Geofence = loop
+			} else {
+				polygon.Holes = append(polygon.Holes, loop)
+			}
+		}
+		if polygon.Geofence != nil {
+			polygons = append(polygons, polygon)
+		}
+	}
+	return polygons
+}
+
+// MaxPolyfillSize returns the size of the buffer Polyfill needs, an upper
+// bound of the number of indexes filling the polygon.
+func (c *H3) MaxPolyfillSize(polygon GeoPolygon, res int) int {
+	p, free := c.geoPolygon(polygon)
+	defer free()
+	return int(ch3.XmaxPolyfillSize(c.TLS, p, int32(res)))
+}
+
+// Polyfill returns the indexes whose center is inside the polygon.
+func (c *H3) Polyfill(polygon GeoPolygon, res int) []Index {
+	p, free := c.geoPolygon(polygon)
+	defer free()
+	n := int(ch3.XmaxPolyfillSize(c.TLS, p, int32(res)))
+	if n <= 0 {
+		return nil
+	}
+	out := c.calloc(n, indexSize)
+	defer c.free(out)
+	ch3.Xpolyfill(c.TLS, p, int32(res), out)
+	return readIndexes(out, n)
+}
+
+// geoPolygon copies a polygon into library memory, in radians, and returns it
+// with the function freeing it.
+func (c *H3) geoPolygon(polygon GeoPolygon) (uintptr, func()) {
+	allocs := []uintptr{}
+	geofence := func(loop []GeoCoord) ch3.TGeofence {
+		verts := c.calloc(len(loop)+1, int(unsafe.Sizeof(ch3.TGeoCoord{})))
+		allocs = append(allocs, verts)
+		vs := unsafe.Slice((*ch3.TGeoCoord)(pointer(verts)), len(loop))
+		for i, g := range loop {
+			vs[i] = ch3.TGeoCoord{Flat: deg2rad * g.Latitude, Flon: deg2rad * g.Longitude}
+		}
+		return ch3.TGeofence{FnumVerts: int32(len(loop)), Fverts: verts}
+	}
+	p := c.calloc(1, int(unsafe.Sizeof(ch3.TGeoPolygon{})))
+	allocs = append(allocs, p)
+	gp := (*ch3.TGeoPolygon)(pointer(p))
+	gp.Fgeofence = geofence(polygon.Geofence)
+	if len(polygon.Holes) > 0 {
+		holes := c.calloc(len(polygon.Holes), int(unsafe.Sizeof(ch3.TGeofence{})))
+		allocs = append(allocs, holes)
+		hs := unsafe.Slice((*ch3.TGeofence)(pointer(holes)), len(polygon.Holes))
+		for i, h := range polygon.Holes {
+			hs[i] = geofence(h)
+		}
+		gp.FnumHoles = int32(len(polygon.Holes))
+		gp.Fholes = holes
+	}
+	return p, func() {
+		for _, a := range allocs {
+			c.free(a)
+		}
+	}
+}
+
+const indexSize = int(unsafe.Sizeof(ch3.TH3Index(0)))
+
+// calloc allocates zeroed memory owned by the transpiled library. Buffers
+// handed to ch3 must not live in Go memory, since the runtime may move it
+// while the library still holds its address.
+func (c *H3) calloc(n, size int) uintptr {
+	return libc.Xcalloc(c.TLS, ch3.Tsize_t(n), ch3.Tsize_t(size))
+}
+
+func (c *H3) free(p uintptr) {
+	libc.Xfree(c.TLS, p)
+}
+
+// readIndexes copies n indexes out of library memory, skipping the empty
+// slots some H3 functions leave in their output buffers.
+func readIndexes(p uintptr, n int) []Index {
+	hs := make([]Index, 0, n)
+	for _, h := range unsafe.Slice((*Index)(pointer(p)), n) {
+		if h != 0 {
+			hs = append(hs, h)
+		}
+	}
+	return hs
+}
+
+// pointer converts an address of library memory back into a pointer. The
+// memory is not managed by the Go runtime so the conversion is safe.
+func pointer(p uintptr) unsafe.Pointer {
+	return *(*unsafe.Pointer)(unsafe.Pointer(&p))
+}
@@ -0,0 +1,192 @@
+package placekey
+
+import (
+	"errors"
+	"io"
+	"math"
+
+	"github.com/diegosz/placekey-go/internal/h3"
+)
+
+// JoinMode selects how records of two datasets are matched.
+type JoinMode int
+
+const (
+	// JoinExact matches records sharing a PlaceKey.
+	JoinExact JoinMode = iota
+	// JoinNeighbors matches records whose PlaceKeys are within K grid steps.
+	JoinNeighbors
+	// JoinDistance matches records within a distance in meters.
+	JoinDistance
+)
+
+var ErrInvalidJoinMode = errors.New("invalid join mode")
+
+// JoinOptions configures a join.
+type JoinOptions struct {
+	Mode JoinMode
+	// K is the number of grid steps of JoinNeighbors, 1 when zero.
+	K int
+	// Meters is the distance threshold of JoinDistance.
+	Meters float64
+}
+
+// JoinMatch is a pair of matching records with the distance in meters between
+// them, see RecordDistance.
+type JoinMatch struct {
+	Left, Right Record
+	Distance    float64
+}
+
+// Join matches the records of two datasets through their PlaceKeys, computing
+// the PlaceKeys of records located by coordinates. The right dataset is read
+// into an index first, then the left dataset is streamed and emit is called
+// for each match, in the order of the left records.
+func (c *H3) Join(left, right RecordReader, opts JoinOptions, emit func(JoinMatch) error) error {
+	if opts.Mode < JoinExact || opts.Mode > JoinDistance {
+		return ErrInvalidJoinMode
+	}
+	if opts.K < 0 {
+		return ErrInvalidK
+	}
+	if opts.K == 0 {
+		opts.K = 1
+	}
+	if opts.Meters < 0 || math.IsNaN(opts.Meters) {
+		return ErrInvalidRadius
+	}
+	idx := NewIndex[Record]()
+	for {
+		r, err := right.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if err := c.Locate(&r); err != nil {
+			return err
+		}
+		if err := idx.Insert(r.PlaceKey, r); err != nil {
+			return err
+		}
+	}
+	for {
+		l, err := left.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := c.Locate(&l); err != nil {
+			return err
+		}
+		cells, err := c.joinCandidates(l, opts)
+		if err != nil {
+			return err
+		}
+		for _, cell := range cells {
+			items, err := idx.Get(cell)
+			if err != nil {
+				return err
+			}
+			for _, item := range items {
+				d, err := c.RecordDistance(l, item.Value)
+				if err != nil {
+					return err
+				}
+				if opts.Mode == JoinDistance && d > opts.Meters {
+					continue
+				}
+				if err := emit(JoinMatch{Left: l, Right: item.Value, Distance: d}); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+// joinCandidates returns the PlaceKeys of the cells where the matches of a
+// record can be found.
+func (c *H3) joinCandidates(r Record, opts JoinOptions) ([]string, error) {
+	switch opts.Mode {
+	case JoinNeighbors:
+		x, err := ToH3Index(r.PlaceKey)
+		if err != nil {
+			return nil, err
+		}
+		cells := []string{}
+		for _, n := range c.h3.KRing(x, opts.K) {
+			cells = append(cells, encodeH3Int(uint64(n)))
+		}
+		return cells, nil
+	case JoinDistance:
+		if r.HasGeo {
+			return c.CoverCircle(r.Lat, r.Lng, opts.Meters, ContainmentIntersects)
+		}
+		x, err := ToH3Index(r.PlaceKey)
+		if err != nil {
+			return nil, err
+		}
+		lat, lng, ring := c.cellGeo(x)
+		return c.CoverCircle(lat, lng, opts.Meters+circumradius(lat, lng, ring), ContainmentIntersects)
+	default:
+		return []string{r.PlaceKey}, nil
+	}
+}
+
+// RecordDistance returns the boundary-aware distance in meters between two
+// records: between their coordinates when both have coordinates, from the
+// coordinates of one to the boundary of the PlaceKey of the other when only
+// one has, and between the boundaries of their PlaceKeys otherwise. A
+// coordinate inside a PlaceKey is at distance zero from it.
+func (c *H3) RecordDistance(r1, r2 Record) (float64, error) {
+	if r1.HasGeo && r2.HasGeo {
+		return geoDistance(r1.Lat, r1.Lng, r2.Lat, r2.Lng), nil
+	}
+	if r2.HasGeo {
+		r1, r2 = r2, r1
+	}
+	x2, err := ToH3Index(r2.PlaceKey)
+	if err != nil {
+		return 0, err
+	}
+	_, _, ring2 := c.cellGeo(x2)
+	if r1.HasGeo {
+		return boundaryDistance(r1.Lat, r1.Lng, ring2), nil
+	}
+	x1, err := ToH3Index(r1.PlaceKey)
+	if err != nil {
+		return 0, err
+	}
+	return c.cellsDistance(x1, x2, ring2), nil
+}
+
+// cellsDistance returns the distance in meters between the boundaries of two
+// cells, zero when they touch. The nearest points of two disjoint convex
+// polygons include a vertex of one of them.
+func (c *H3) cellsDistance(x1, x2 h3.Index, ring2 [][]float64) float64 {
+	if x1 == x2 || c.h3.Distance(x1, x2) == 1 {
+		return 0
+	}
+	_, _, ring1 := c.cellGeo(x1)
+	d := math.Inf(1)
+	for _, v := range ring1 {
+		d = math.Min(d, boundaryDistance(v[0], v[1], ring2))
+	}
+	for _, v := range ring2 {
+		d = math.Min(d, boundaryDistance(v[0], v[1], ring1))
+	}
+	return d
+}
+
+// circumradius returns the largest distance in meters from a center to the
+// vertices of a ring.
+func circumradius(lat, lng float64, ring [][]float64) float64 {
+	r := 0.0
+	for _, v := range ring {
+		r = math.Max(r, geoDistance(lat, lng, v[0], v[1]))
+	}
+	return r
+}
@@ -0,0 +1,114 @@
+package placekey
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestH3_Join(t *testing.T) {
+	points := "id,lat,lng\n" +
+		"a,37.779274,-122.419262\n" +
+		"b,37.7794,-122.4185\n" +
+		"c,37.795424,-122.393715\n"
+	pois := `{"name":"city hall","placekey":"@5vg-7gq-tvz"}` + "\n" +
+		`{"name":"ferry building","lat":37.7955,"lng":-122.3937}` + "\n"
+	tests := []struct {
+		name    string
+		opts    JoinOptions
+		want    []string
+		wantErr error
+	}{
+		{
+			name: "exact",
+			opts: JoinOptions{Mode: JoinExact},
+			want: []string{"a-city hall", "c-ferry building"},
+		},
+		{
+			name: "neighbors",
+			opts: JoinOptions{Mode: JoinNeighbors},
+			want: []string{"a-city hall", "b-city hall", "c-ferry building"},
+		},
+		{
+			name: "distance",
+			opts: JoinOptions{Mode: JoinDistance, Meters: 50},
+			want: []string{"a-city hall", "b-city hall", "c-ferry building"},
+		},
+		{
+			name: "short distance",
+			opts: JoinOptions{Mode: JoinDistance, Meters: 5},
+			want: []string{"a-city hall"},
+		},
+		{
+			name:    "invalid mode",
+			opts:    JoinOptions{Mode: JoinMode(9)},
+			wantErr: ErrInvalidJoinMode,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewH3()
+			defer c.Close()
+			left := NewCSVRecordReader(strings.NewReader(points), DefaultRecordFields)
+			right := NewNDJSONRecordReader(strings.NewReader(pois), DefaultRecordFields)
+			got := []string{}
+			err := c.Join(left, right, tt.opts, func(m JoinMatch) error {
+				if m.Distance < 0 {
+					t.Errorf("Join() negative distance %v", m.Distance)
+				}
+				got = append(got, m.Left.Fields["id"]+"-"+m.Right.Fields["name"])
+				return nil
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Join() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("Join() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestH3_RecordDistance(t *testing.T) {
+	c := NewH3()
+	defer c.Close()
+	cityHall := Record{PlaceKey: "@5vg-7gq-tvz"}
+	inside := Record{Lat: 37.779274, Lng: -122.419262, HasGeo: true}
+	ferry := Record{Lat: 37.795424, Lng: -122.393715, HasGeo: true}
+	d, err := c.RecordDistance(inside, cityHall)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d != 0 {
+		t.Errorf("RecordDistance() inside got = %v", d)
+	}
+	d1, err := c.RecordDistance(ferry, inside)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d2, err := c.RecordDistance(cityHall, ferry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d2 >= d1 || d1-d2 > 100 {
+		t.Errorf("RecordDistance() point %v, boundary %v", d1, d2)
+	}
+	neighbor, err := c.GridPath("@5vg-7gq-tvz", "@5vg-7gt-qzz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err = c.RecordDistance(cityHall, Record{PlaceKey: neighbor[1]})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d != 0 {
+		t.Errorf("RecordDistance() neighbors got = %v", d)
+	}
+	d, err = c.RecordDistance(cityHall, Record{PlaceKey: neighbor[3]})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d < 100 || d > 300 {
+		t.Errorf("RecordDistance() cells got = %v", d)
+	}
+}
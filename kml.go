@@ -0,0 +1,366 @@
+package placekey
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"image/color"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/diegosz/placekey-go/internal/h3"
+)
+
+// kmlNamespace is the XML namespace of KML 2.2.
+const kmlNamespace = "http://www.opengis.net/kml/2.2"
+
+var ErrInvalidKML = errors.New("invalid KML")
+
+// KMLOptions configures WriteKML.
+type KMLOptions struct {
+	// Name is the name of the document.
+	Name string
+	// Value is the field of the records filling their hexagons with Scale,
+	// the DefaultColorScale of the range of the values when nil. The records
+	// whose value is missing or not a number are filled with Fill, light grey
+	// when nil.
+	Value string
+	Scale *ColorScale
+	Fill  color.Color
+	// Stroke is the colour of the outlines, dark grey when nil.
+	Stroke color.Color
+	// Points adds the center of each PlaceKey to its Placemark.
+	Points bool
+}
+
+type kmlFile struct {
+	XMLName  xml.Name    `xml:"kml"`
+	Xmlns    string      `xml:"xmlns,attr"`
+	Document kmlDocument `xml:"Document"`
+}
+
+type kmlDocument struct {
+	Name       string         `xml:"name,omitempty"`
+	Styles     []kmlStyle     `xml:"Style"`
+	Placemarks []kmlPlacemark `xml:"Placemark"`
+}
+
+type kmlStyle struct {
+	ID        string `xml:"id,attr"`
+	LineColor string `xml:"LineStyle>color"`
+	PolyColor string `xml:"PolyStyle>color"`
+}
+
+type kmlPlacemark struct {
+	Name          string            `xml:"name,omitempty"`
+	StyleURL      string            `xml:"styleUrl,omitempty"`
+	ExtendedData  *kmlExtendedData  `xml:"ExtendedData"`
+	Point         *kmlPoint         `xml:"Point"`
+	Polygon       *kmlPolygon       `xml:"Polygon"`
+	MultiGeometry *kmlMultiGeometry `xml:"MultiGeometry"`
+}
+
+type kmlExtendedData struct {
+	Data       []kmlData `xml:"Data"`
+	SchemaData []struct {
+		SimpleData []struct {
+			Name  string `xml:"name,attr"`
+			Value string `xml:",chardata"`
+		} `xml:"SimpleData"`
+	} `xml:"SchemaData"`
+}
+
+type kmlData struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value"`
+}
+
+type kmlPoint struct {
+	Coordinates string `xml:"coordinates"`
+}
+
+type kmlPolygon struct {
+	Outer string   `xml:"outerBoundaryIs>LinearRing>coordinates"`
+	Inner []string `xml:"innerBoundaryIs>LinearRing>coordinates"`
+}
+
+type kmlMultiGeometry struct {
+	Points        []kmlPoint         `xml:"Point"`
+	Polygons      []kmlPolygon       `xml:"Polygon"`
+	MultiGeometry []kmlMultiGeometry `xml:"MultiGeometry"`
+}
+
+// WriteKML writes records as KML Placemarks, located with Locate. Each
+// Placemark is named by its PlaceKey, holds the hexagon of the PlaceKey, and
+// its center when KMLOptions.Points is set, and has the fields of the record
+// as ExtendedData in alphabetical order.
+func (c *H3) WriteKML(w io.Writer, records []Record, opts KMLOptions) error {
+	if opts.Fill == nil {
+		opts.Fill = color.NRGBA{R: 0xcc, G: 0xcc, B: 0xcc, A: 0xff}
+	}
+	if opts.Stroke == nil {
+		opts.Stroke = color.NRGBA{R: 0x33, G: 0x33, B: 0x33, A: 0xff}
+	}
+	values := map[int]float64{}
+	if opts.Value != "" {
+		for i, r := range records {
+			if v, err := strconv.ParseFloat(r.Fields[opts.Value], 64); err == nil && !math.IsNaN(v) {
+				values[i] = v
+			}
+		}
+	}
+	scale := opts.Scale
+	if scale == nil {
+		min, max := math.Inf(1), math.Inf(-1)
+		for _, v := range values {
+			min, max = math.Min(min, v), math.Max(max, v)
+		}
+		s := DefaultColorScale(min, max)
+		scale = &s
+	}
+
+	doc := kmlDocument{Name: opts.Name}
+	lineColor := kmlColor(opts.Stroke)
+	styles := map[string]string{}
+	for i := range records {
+		r := records[i]
+		if err := c.Locate(&r); err != nil {
+			return err
+		}
+		x, err := ToH3Index(r.PlaceKey)
+		if err != nil {
+			return err
+		}
+		fill := opts.Fill
+		if v, ok := values[i]; ok {
+			fill = scale.Color(v)
+		}
+		polyColor := kmlColor(fill)
+		id, ok := styles[polyColor]
+		if !ok {
+			id = "style" + strconv.Itoa(len(styles))
+			styles[polyColor] = id
+			doc.Styles = append(doc.Styles, kmlStyle{ID: id, LineColor: lineColor, PolyColor: polyColor})
+		}
+
+		boundary := c.h3.ToGeoBoundary(x)
+		ring := make([]string, 0, len(boundary)+1)
+		for _, g := range append(boundary, boundary[0]) {
+			ring = append(ring, kmlCoordinate(g))
+		}
+		polygon := &kmlPolygon{Outer: strings.Join(ring, " ")}
+		p := kmlPlacemark{Name: r.PlaceKey, StyleURL: "#" + id}
+		if opts.Points {
+			p.MultiGeometry = &kmlMultiGeometry{
+				Points:   []kmlPoint{{Coordinates: kmlCoordinate(c.h3.ToGeo(x))}},
+				Polygons: []kmlPolygon{*polygon},
+			}
+		} else {
+			p.Polygon = polygon
+		}
+		if len(r.Fields) > 0 {
+			names := make([]string, 0, len(r.Fields))
+			for k := range r.Fields {
+				names = append(names, k)
+			}
+			sort.Strings(names)
+			p.ExtendedData = &kmlExtendedData{}
+			for _, k := range names {
+				p.ExtendedData.Data = append(p.ExtendedData.Data, kmlData{Name: k, Value: r.Fields[k]})
+			}
+		}
+		doc.Placemarks = append(doc.Placemarks, p)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(kmlFile{Xmlns: kmlNamespace, Document: doc}); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// kmlColor returns a colour as a KML aabbggrr hexadecimal colour.
+func kmlColor(c color.Color) string {
+	n := color.NRGBAModel.Convert(c).(color.NRGBA)
+	return fmt.Sprintf("%02x%02x%02x%02x", n.A, n.B, n.G, n.R)
+}
+
+// kmlCoordinate returns a coordinate as a KML longitude,latitude tuple.
+func kmlCoordinate(g GeoCoord) string {
+	return formatFloat(g.Longitude) + "," + formatFloat(g.Latitude)
+}
+
+// ReadKML reads the Placemarks of a KML document, in Folders or not, as
+// records. The Points of a Placemark give records located by their
+// coordinates, and its Polygons the records of the PlaceKeys they cover, in
+// the order of their H3 indexes, each PlaceKey once per Placemark. The records
+// take the ExtendedData of their Placemark as fields, with its name as the
+// name field unless the ExtendedData has one.
+func (c *H3) ReadKML(r io.Reader) ([]Record, error) {
+	dec := xml.NewDecoder(r)
+	records := []Record{}
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidKML, err)
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok || start.Name.Local != "Placemark" {
+			continue
+		}
+		var p kmlPlacemark
+		if err := dec.DecodeElement(&p, &start); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidKML, err)
+		}
+		rs, err := c.readPlacemark(p)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, rs...)
+	}
+}
+
+// readPlacemark converts a KML Placemark into records.
+func (c *H3) readPlacemark(p kmlPlacemark) ([]Record, error) {
+	fields := map[string]string{}
+	if p.ExtendedData != nil {
+		for _, d := range p.ExtendedData.Data {
+			fields[d.Name] = d.Value
+		}
+		for _, s := range p.ExtendedData.SchemaData {
+			for _, d := range s.SimpleData {
+				fields[d.Name] = d.Value
+			}
+		}
+	}
+	if _, ok := fields["name"]; !ok && strings.TrimSpace(p.Name) != "" {
+		fields["name"] = strings.TrimSpace(p.Name)
+	}
+
+	geometry := kmlMultiGeometry{}
+	if p.Point != nil {
+		geometry.Points = append(geometry.Points, *p.Point)
+	}
+	if p.Polygon != nil {
+		geometry.Polygons = append(geometry.Polygons, *p.Polygon)
+	}
+	if p.MultiGeometry != nil {
+		geometry.MultiGeometry = append(geometry.MultiGeometry, *p.MultiGeometry)
+	}
+	var points []GeoCoord
+	var polygons []GeoPolygon
+	var flatten func(m kmlMultiGeometry) error
+	flatten = func(m kmlMultiGeometry) error {
+		for _, point := range m.Points {
+			coords, err := kmlCoordinates(point.Coordinates)
+			if err != nil {
+				return err
+			}
+			if len(coords) != 1 {
+				return fmt.Errorf("%w: point of %d coordinates", ErrInvalidKML, len(coords))
+			}
+			points = append(points, coords[0])
+		}
+		for _, polygon := range m.Polygons {
+			var gp GeoPolygon
+			for i, s := range append([]string{polygon.Outer}, polygon.Inner...) {
+				ring, err := kmlCoordinates(s)
+				if err != nil {
+					return err
+				}
+				if len(ring) > 1 && ring[0] == ring[len(ring)-1] {
+					ring = ring[:len(ring)-1]
+				}
+				if len(ring) < 3 {
+					return ErrInvalidPolygon
+				}
+				if i == 0 {
+					gp.Geofence = ring
+				} else {
+					gp.Holes = append(gp.Holes, ring)
+				}
+			}
+			polygons = append(polygons, gp)
+		}
+		for _, child := range m.MultiGeometry {
+			if err := flatten(child); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := flatten(geometry); err != nil {
+		return nil, err
+	}
+
+	records := []Record{}
+	seen := map[string]bool{}
+	for _, g := range points {
+		pk, err := c.FromGeo(g.Latitude, g.Longitude)
+		if err != nil {
+			return nil, err
+		}
+		if seen[pk] {
+			continue
+		}
+		seen[pk] = true
+		records = append(records, Record{PlaceKey: pk, Lat: g.Latitude, Lng: g.Longitude, HasGeo: true, Fields: copyFields(fields)})
+	}
+	cells := map[h3.Index]bool{}
+	for _, polygon := range polygons {
+		if float64(c.h3.MaxPolyfillSize(polygon, resolution)) > maxCoverCells {
+			return nil, ErrCoverTooLarge
+		}
+		for _, x := range c.h3.Polyfill(polygon, resolution) {
+			cells[x] = true
+		}
+	}
+	xs := make([]h3.Index, 0, len(cells))
+	for x := range cells {
+		xs = append(xs, x)
+	}
+	sort.Slice(xs, func(i, j int) bool { return xs[i] < xs[j] })
+	for _, x := range xs {
+		pk := encodeH3Int(uint64(x))
+		if seen[pk] {
+			continue
+		}
+		seen[pk] = true
+		records = append(records, Record{PlaceKey: pk, Fields: copyFields(fields)})
+	}
+	return records, nil
+}
+
+// kmlCoordinates parses the whitespace separated longitude,latitude[,altitude]
+// tuples of a KML coordinates element.
+func kmlCoordinates(s string) ([]GeoCoord, error) {
+	tuples := strings.Fields(s)
+	coords := make([]GeoCoord, 0, len(tuples))
+	for _, t := range tuples {
+		parts := strings.Split(t, ",")
+		if len(parts) < 2 || len(parts) > 3 {
+			return nil, fmt.Errorf("%w: coordinates %q", ErrInvalidKML, t)
+		}
+		lng, err := strconv.ParseFloat(parts[0], 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: coordinates %q", ErrInvalidKML, t)
+		}
+		lat, err := strconv.ParseFloat(parts[1], 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: coordinates %q", ErrInvalidKML, t)
+		}
+		coords = append(coords, GeoCoord{Latitude: lat, Longitude: lng})
+	}
+	return coords, nil
+}
@@ -0,0 +1,170 @@
+package placekey
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"image/color"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestH3_WriteKML(t *testing.T) {
+	c := NewH3()
+	defer c.Close()
+	records := []Record{
+		{PlaceKey: "@5vg-7gq-tvz", Fields: map[string]string{"name": "City Hall", "visits": "12"}},
+		{PlaceKey: "zzw-222@5vg-7gt-qzz", Fields: map[string]string{"name": "Ferry & Pier", "visits": "3"}},
+		{Lat: 37.7599, Lng: -122.4148, HasGeo: true, Fields: map[string]string{"visits": "n/a"}},
+	}
+	var b bytes.Buffer
+	if err := c.WriteKML(&b, records, KMLOptions{Name: "visits", Value: "visits", Points: true}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(b.String(), xml.Header+`<kml xmlns="http://www.opengis.net/kml/2.2">`) {
+		t.Errorf("WriteKML() got %s", b.String())
+	}
+	var file kmlFile
+	if err := xml.Unmarshal(b.Bytes(), &file); err != nil {
+		t.Fatal(err)
+	}
+	doc := file.Document
+	if doc.Name != "visits" || len(doc.Placemarks) != 3 {
+		t.Fatalf("WriteKML() got %+v", doc)
+	}
+	wantColors := []string{
+		kmlColor(DefaultColorScale(3, 12).To),
+		kmlColor(DefaultColorScale(3, 12).From),
+		"ffcccccc",
+	}
+	styles := map[string]string{}
+	for _, s := range doc.Styles {
+		styles["#"+s.ID] = s.PolyColor
+		if s.LineColor != "ff333333" {
+			t.Errorf("WriteKML() got line color %s", s.LineColor)
+		}
+	}
+	mission, err := c.FromGeo(37.7599, -122.4148)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, want := range []string{"@5vg-7gq-tvz", "zzw-222@5vg-7gt-qzz", mission} {
+		p := doc.Placemarks[i]
+		if p.Name != want || styles[p.StyleURL] != wantColors[i] {
+			t.Errorf("WriteKML() got placemark %d named %s with color %s", i, p.Name, styles[p.StyleURL])
+		}
+		if p.MultiGeometry == nil || len(p.MultiGeometry.Points) != 1 || len(p.MultiGeometry.Polygons) != 1 {
+			t.Fatalf("WriteKML() got geometry %+v", p.MultiGeometry)
+		}
+		ring, err := kmlCoordinates(p.MultiGeometry.Polygons[0].Outer)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(ring) != 7 || ring[0] != ring[6] {
+			t.Errorf("WriteKML() got ring %v", ring)
+		}
+	}
+	data := doc.Placemarks[1].ExtendedData
+	if data == nil || !reflect.DeepEqual(data.Data, []kmlData{{"name", "Ferry & Pier"}, {"visits", "3"}}) {
+		t.Errorf("WriteKML() got extended data %+v", data)
+	}
+
+	scale := ColorScale{Min: 0, Max: 1, From: color.White, To: color.NRGBA{R: 0xff, A: 0x80}}
+	b.Reset()
+	if err := c.WriteKML(&b, records[:1], KMLOptions{Value: "visits", Scale: &scale}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(b.String(), "<color>800000ff</color>") || strings.Contains(b.String(), "<MultiGeometry>") {
+		t.Errorf("WriteKML() got %s", b.String())
+	}
+	if err := c.WriteKML(&b, []Record{{}}, KMLOptions{}); !errors.Is(err, ErrMissingLocation) {
+		t.Errorf("WriteKML() error = %v", err)
+	}
+}
+
+func TestH3_ReadKML(t *testing.T) {
+	c := NewH3()
+	defer c.Close()
+
+	// written placemarks read back as their PlaceKeys
+	records := []Record{
+		{PlaceKey: "@5vg-7gq-tvz", Fields: map[string]string{"visits": "12"}},
+		{PlaceKey: "@5vg-82n-kzz", Fields: map[string]string{"name": "Pier"}},
+	}
+	for _, points := range []bool{false, true} {
+		var b bytes.Buffer
+		if err := c.WriteKML(&b, records, KMLOptions{Points: points}); err != nil {
+			t.Fatal(err)
+		}
+		got, err := c.ReadKML(&b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != 2 || got[0].PlaceKey != "@5vg-7gq-tvz" || got[1].PlaceKey != "@5vg-82n-kzz" || got[0].HasGeo != points {
+			t.Fatalf("ReadKML() got %+v", got)
+		}
+		want := []map[string]string{{"name": "@5vg-7gq-tvz", "visits": "12"}, {"name": "Pier"}}
+		for i, r := range got {
+			if !reflect.DeepEqual(r.Fields, want[i]) {
+				t.Errorf("ReadKML() got fields %v, want %v", r.Fields, want[i])
+			}
+		}
+	}
+
+	// a square of about 1 km² with a hole, in a folder, and a point
+	doc := `<?xml version="1.0" encoding="UTF-8"?>
+<kml xmlns="http://www.opengis.net/kml/2.2"><Document><Folder>
+  <Placemark>
+    <name>Square</name>
+    <ExtendedData><SchemaData schemaUrl="#s"><SimpleData name="kind">park</SimpleData></SchemaData></ExtendedData>
+    <Polygon>
+      <outerBoundaryIs><LinearRing><coordinates>
+        -122.42,37.77,0 -122.41,37.77,0 -122.41,37.78,0 -122.42,37.78,0 -122.42,37.77,0
+      </coordinates></LinearRing></outerBoundaryIs>
+      <innerBoundaryIs><LinearRing><coordinates>
+        -122.417,37.773 -122.417,37.777 -122.413,37.777 -122.413,37.773 -122.417,37.773
+      </coordinates></LinearRing></innerBoundaryIs>
+    </Polygon>
+  </Placemark>
+</Folder>
+<Placemark><Point><coordinates>-122.4193,37.7793</coordinates></Point></Placemark>
+</Document></kml>`
+	got, err := c.ReadKML(strings.NewReader(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// about 1 km² less the hole, with cells of 0.015 km²
+	if len(got) < 50 || len(got) > 80 {
+		t.Fatalf("ReadKML() got %d records", len(got))
+	}
+	for _, r := range got[:len(got)-1] {
+		lat, lng, err := c.ToGeo(r.PlaceKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		inSquare := lat >= 37.77 && lat <= 37.78 && lng >= -122.42 && lng <= -122.41
+		inHole := lat > 37.773 && lat < 37.777 && lng > -122.417 && lng < -122.413
+		if !inSquare || inHole || !reflect.DeepEqual(r.Fields, map[string]string{"kind": "park", "name": "Square"}) {
+			t.Errorf("ReadKML() got %+v at (%v, %v)", r, lat, lng)
+		}
+	}
+	if last := got[len(got)-1]; last.PlaceKey != "@5vg-7gq-tvz" || !last.HasGeo || len(last.Fields) != 0 {
+		t.Errorf("ReadKML() got %+v", last)
+	}
+
+	for _, tt := range []struct {
+		doc string
+		err error
+	}{
+		{`<kml><Placemark>`, ErrInvalidKML},
+		{`<kml><Placemark><Point><coordinates>1</coordinates></Point></Placemark></kml>`, ErrInvalidKML},
+		{`<kml><Placemark><Point><coordinates>1,2 3,4</coordinates></Point></Placemark></kml>`, ErrInvalidKML},
+		{`<kml><Placemark><Point><coordinates>0,91</coordinates></Point></Placemark></kml>`, ErrInvalidLatLngRange},
+		{`<kml><Placemark><Polygon><outerBoundaryIs><LinearRing><coordinates>0,0 1,1 0,0</coordinates></LinearRing></outerBoundaryIs></Polygon></Placemark></kml>`, ErrInvalidPolygon},
+	} {
+		if _, err := c.ReadKML(strings.NewReader(tt.doc)); !errors.Is(err, tt.err) {
+			t.Errorf("ReadKML(%q) error = %v, want %v", tt.doc, err, tt.err)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package placekey
+
+import (
+	"sort"
+)
+
+// Float is the type of the elements of a Matrix.
+type Float interface {
+	~float32 | ~float64
+}
+
+// Matrix is a dense square matrix stored in row-major order.
+type Matrix[F Float] struct {
+	N    int
+	Data []F
+}
+
+// At returns the element of row i and column j.
+func (m *Matrix[F]) At(i, j int) F {
+	return m.Data[i*m.N+j]
+}
+
+// Row returns the row i, sharing the matrix storage.
+func (m *Matrix[F]) Row(i int) []F {
+	return m.Data[i*m.N : (i+1)*m.N]
+}
+
+// Neighbor is a candidate PlaceKey with its position in the candidates and its
+// distance in meters from an origin.
+type Neighbor struct {
+	PlaceKey string
+	Index    int
+	Distance float64
+}
+
+// DistanceMatrix returns the matrix of the distances in meters between the
+// centers of every pair of PlaceKeys. Each PlaceKey is decoded once and the
+// rows are computed in parallel.
+func (c *H3) DistanceMatrix(placeKeys []string) (*Matrix[float64], error) {
+	return distanceMatrix[float64](c, placeKeys)
+}
+
+// DistanceMatrix32 is like DistanceMatrix but returns a matrix of float32,
+// halving its memory.
+func (c *H3) DistanceMatrix32(placeKeys []string) (*Matrix[float32], error) {
+	return distanceMatrix[float32](c, placeKeys)
+}
+
+func distanceMatrix[F Float](c *H3, placeKeys []string) (*Matrix[F], error) {
+	centers, err := c.centroids(placeKeys)
+	if err != nil {
+		return nil, err
+	}
+	n := len(centers)
+	m := &Matrix[F]{N: n, Data: make([]F, n*n)}
+	err = c.parallel(n, func(_ *H3, lo, hi int) error {
+		for i := lo; i < hi; i++ {
+			for j := 0; j < n; j++ {
+				if i != j {
+					m.Data[i*n+j] = F(geoDistance(centers[i][0], centers[i][1], centers[j][0], centers[j][1]))
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// KNearest returns the k candidates nearest to an origin PlaceKey, sorted by
+// distance between centers. Fewer neighbors are returned when there are less
+// than k candidates.
+func (c *H3) KNearest(origin string, candidates []string, k int) ([]Neighbor, error) {
+	if k < 0 {
+		return nil, ErrInvalidK
+	}
+	lat, lng, err := c.ToGeo(origin)
+	if err != nil {
+		return nil, err
+	}
+	centers, err := c.centroids(candidates)
+	if err != nil {
+		return nil, err
+	}
+	neighbors := make([]Neighbor, len(candidates))
+	err = c.parallel(len(candidates), func(_ *H3, lo, hi int) error {
+		for i := lo; i < hi; i++ {
+			neighbors[i] = Neighbor{
+				PlaceKey: candidates[i],
+				Index:    i,
+				Distance: geoDistance(lat, lng, centers[i][0], centers[i][1]),
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(neighbors, func(i, j int) bool {
+		return neighbors[i].Distance < neighbors[j].Distance
+	})
+	if k < len(neighbors) {
+		neighbors = neighbors[:k]
+	}
+	return neighbors, nil
+}
+
+// centroids decodes the centers of PlaceKeys in parallel.
+func (c *H3) centroids(placeKeys []string) ([][2]float64, error) {
+	centers := make([][2]float64, len(placeKeys))
+	err := c.parallel(len(placeKeys), func(c *H3, lo, hi int) error {
+		for i := lo; i < hi; i++ {
+			lat, lng, err := c.ToGeo(placeKeys[i])
+			if err != nil {
+				return err
+			}
+			centers[i] = [2]float64{lat, lng}
+		}
+		return nil
+	})
+	return centers, err
+}
@@ -0,0 +1,98 @@
+package placekey
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func testPlaceKeys(t *testing.T, c *H3, n int) []string {
+	t.Helper()
+	placeKeys := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		pk, err := c.FromGeo(37.7+float64(i%37)*0.003, -122.5+float64(i/37)*0.003)
+		if err != nil {
+			t.Fatal(err)
+		}
+		placeKeys = append(placeKeys, pk)
+	}
+	return placeKeys
+}
+
+func TestH3_DistanceMatrix(t *testing.T) {
+	c := NewH3()
+	defer c.Close()
+	placeKeys := testPlaceKeys(t, c, 600)
+	m, err := c.DistanceMatrix(placeKeys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m32, err := c.DistanceMatrix32(placeKeys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.N != len(placeKeys) || len(m.Data) != m.N*m.N {
+		t.Fatalf("DistanceMatrix() got size %d", m.N)
+	}
+	for _, ij := range [][2]int{{0, 0}, {0, 1}, {5, 599}, {599, 5}, {300, 42}} {
+		want, err := c.Distance(placeKeys[ij[0]], placeKeys[ij[1]])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := m.At(ij[0], ij[1]); !almostEqual(got, want) {
+			t.Errorf("DistanceMatrix() at %v got = %v, want %v", ij, got, want)
+		}
+		if got := float64(m32.At(ij[0], ij[1])); math.Abs(got-want) > 0.01 {
+			t.Errorf("DistanceMatrix32() at %v got = %v, want %v", ij, got, want)
+		}
+	}
+	if len(m.Row(3)) != m.N || m.Row(3)[7] != m.At(3, 7) {
+		t.Error("Row() does not match At()")
+	}
+	if _, err := c.DistanceMatrix([]string{"@5vg-7gq-tvz", "@5vg@7gq@tvz"}); !errors.Is(err, ErrInvalidParts) {
+		t.Errorf("DistanceMatrix() error = %v, wantErr %v", err, ErrInvalidParts)
+	}
+}
+
+func TestH3_KNearest(t *testing.T) {
+	c := NewH3()
+	defer c.Close()
+	candidates := testPlaceKeys(t, c, 600)
+	origin := "@5vg-7gq-tvz"
+	got, err := c.KNearest(origin, candidates, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 10 {
+		t.Fatalf("KNearest() got %d neighbors", len(got))
+	}
+	for i, n := range got {
+		if candidates[n.Index] != n.PlaceKey {
+			t.Errorf("KNearest() neighbor %d index mismatch", i)
+		}
+		if i > 0 && n.Distance < got[i-1].Distance {
+			t.Errorf("KNearest() not sorted at %d", i)
+		}
+	}
+	farthest := got[len(got)-1].Distance
+	closer := 0
+	for _, pk := range candidates {
+		d, err := c.Distance(origin, pk)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if d < farthest {
+			closer++
+		}
+	}
+	if closer >= 10 {
+		t.Errorf("KNearest() missed neighbors, %d candidates closer than %v", closer, farthest)
+	}
+	all, err := c.KNearest(origin, candidates[:3], 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 3 {
+		t.Errorf("KNearest() got %d neighbors, want 3", len(all))
+	}
+}
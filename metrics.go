@@ -0,0 +1,141 @@
+package placekey
+
+import (
+	"math"
+)
+
+// BoundingBox is a geographic bounding box in degrees.
+//
+// When the box crosses the antimeridian East is smaller than West, so the box
+// spans from West eastwards to 180 and from -180 eastwards to East.
+type BoundingBox struct {
+	North, South, East, West float64
+}
+
+// CrossesAntimeridian returns whether or not the bounding box crosses the
+// antimeridian.
+func (b BoundingBox) CrossesAntimeridian() bool {
+	return b.East < b.West
+}
+
+// Contains returns whether or not a (latitude, longitude) is inside the
+// bounding box.
+func (b BoundingBox) Contains(lat, lng float64) bool {
+	if lat < b.South || lat > b.North {
+		return false
+	}
+	if b.CrossesAntimeridian() {
+		return lng >= b.West || lng <= b.East
+	}
+	return lng >= b.West && lng <= b.East
+}
+
+// Area returns the area in square meters of a PlaceKey, computed from its
+// actual boundary on the sphere.
+func (c *H3) Area(placeKey string) (float64, error) {
+	x, err := ToH3Index(placeKey)
+	if err != nil {
+		return 0, err
+	}
+	return c.h3.CellAreaM2(x), nil
+}
+
+// EdgeLength returns the mean length in meters of the edges of a PlaceKey.
+func (c *H3) EdgeLength(placeKey string) (float64, error) {
+	x, err := ToH3Index(placeKey)
+	if err != nil {
+		return 0, err
+	}
+	ls := c.h3.EdgeLengthsM(x)
+	if len(ls) == 0 {
+		return 0, ErrInvalidCell
+	}
+	sum := 0.0
+	for _, l := range ls {
+		sum += l
+	}
+	return sum / float64(len(ls)), nil
+}
+
+// BoundingBox returns the bounding box of a PlaceKey boundary.
+func (c *H3) BoundingBox(placeKey string) (BoundingBox, error) {
+	boundary, err := c.ToGeoBoundary(placeKey)
+	if err != nil {
+		return BoundingBox{}, err
+	}
+	return boundingBox(boundary), nil
+}
+
+// IsPentagon returns whether or not a PlaceKey is one of the twelve
+// resolution 10 pentagons.
+func (c *H3) IsPentagon(placeKey string) (bool, error) {
+	x, err := ToH3Index(placeKey)
+	if err != nil {
+		return false, err
+	}
+	return c.h3.IsPentagon(x), nil
+}
+
+// BaseCell returns the H3 resolution 0 base cell number (0 to 121) of a
+// PlaceKey.
+func (c *H3) BaseCell(placeKey string) (int, error) {
+	x, err := ToH3Index(placeKey)
+	if err != nil {
+		return 0, err
+	}
+	return c.h3.BaseCell(x), nil
+}
+
+// Faces returns the icosahedron faces (0 to 19) intersected by a PlaceKey.
+func (c *H3) Faces(placeKey string) ([]int, error) {
+	x, err := ToH3Index(placeKey)
+	if err != nil {
+		return nil, err
+	}
+	return c.h3.Faces(x), nil
+}
+
+// boundingBox returns the bounding box of a ring of (latitude, longitude)
+// coordinates. A ring whose longitudes jump by more than 180 degrees is assumed
+// to cross the antimeridian, and a ring winding around a pole extends the box
+// to that pole.
+func boundingBox(ring [][]float64) BoundingBox {
+	if len(ring) == 0 {
+		return BoundingBox{}
+	}
+	b := BoundingBox{North: -90, South: 90, East: -180, West: 180}
+	minPos, maxNeg := 180.0, -180.0
+	crosses := false
+	winding := 0.0
+	for i, p := range ring {
+		lat, lng := p[0], p[1]
+		b.North = math.Max(b.North, lat)
+		b.South = math.Min(b.South, lat)
+		b.East = math.Max(b.East, lng)
+		b.West = math.Min(b.West, lng)
+		if lng >= 0 {
+			minPos = math.Min(minPos, lng)
+		} else {
+			maxNeg = math.Max(maxNeg, lng)
+		}
+		next := ring[(i+1)%len(ring)][1]
+		delta := next - lng
+		if math.Abs(delta) > 180 {
+			crosses = true
+			delta -= math.Copysign(360, delta)
+		}
+		winding += delta
+	}
+	switch {
+	case math.Abs(winding) > 180:
+		if b.North > 0 {
+			b.North = 90
+		} else {
+			b.South = -90
+		}
+		b.East, b.West = 180, -180
+	case crosses:
+		b.East, b.West = maxNeg, minPos
+	}
+	return b
+}
@@ -0,0 +1,162 @@
+package placekey
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestH3_Area(t *testing.T) {
+	tests := []struct {
+		name     string
+		placeKey string
+		want     float64
+	}{
+		{
+			name:     "SF City Hall",
+			placeKey: "@5vg-7gq-tvz",
+			want:     15627.850,
+		},
+		{
+			name:     "antimeridian",
+			placeKey: "@fqs-7dd-xdv",
+			want:     11198.342,
+		},
+		{
+			name:     "pentagon",
+			placeKey: "@nv5-7c8-b49",
+			want:     7592.318,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewH3()
+			defer c.Close()
+			got, err := c.Area(tt.placeKey)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !almostEqual(got, tt.want) {
+				t.Errorf("Area() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestH3_EdgeLength(t *testing.T) {
+	c := NewH3()
+	defer c.Close()
+	got, err := c.EdgeLength("@5vg-7gq-tvz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !almostEqual(got, 77.594) {
+		t.Errorf("EdgeLength() got = %v, want %v", got, 77.594)
+	}
+}
+
+func TestH3_BoundingBox(t *testing.T) {
+	tests := []struct {
+		name        string
+		placeKey    string
+		wantCrosses bool
+		wantNorth   float64
+		wantSouth   float64
+	}{
+		{
+			name:        "SF City Hall",
+			placeKey:    "@5vg-7gq-tvz",
+			wantCrosses: false,
+			wantNorth:   37.779,
+			wantSouth:   37.778,
+		},
+		{
+			name:        "antimeridian",
+			placeKey:    "@fqs-7dd-xdv",
+			wantCrosses: true,
+			wantNorth:   0.000,
+			wantSouth:   -0.001,
+		},
+		{
+			name:        "north pole",
+			placeKey:    "@ah5-5qn-jqf",
+			wantCrosses: false,
+			wantNorth:   90,
+			wantSouth:   89.999,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewH3()
+			defer c.Close()
+			got, err := c.BoundingBox(tt.placeKey)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.CrossesAntimeridian() != tt.wantCrosses {
+				t.Errorf("BoundingBox() got = %v, crosses antimeridian want %v", got, tt.wantCrosses)
+			}
+			if !almostEqual(got.North, tt.wantNorth) || !almostEqual(got.South, tt.wantSouth) {
+				t.Errorf("BoundingBox() got = %v, want north %v south %v", got, tt.wantNorth, tt.wantSouth)
+			}
+			lat, lng, err := c.ToGeo(tt.placeKey)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !got.Contains(lat, lng) {
+				t.Errorf("BoundingBox() got = %v, does not contain center", got)
+			}
+		})
+	}
+}
+
+func TestH3_CellInfo(t *testing.T) {
+	tests := []struct {
+		name         string
+		placeKey     string
+		wantPentagon bool
+		wantBaseCell int
+		wantFaces    []int
+	}{
+		{
+			name:         "SF City Hall",
+			placeKey:     "@5vg-7gq-tvz",
+			wantPentagon: false,
+			wantBaseCell: 20,
+			wantFaces:    []int{7},
+		},
+		{
+			name:         "pentagon",
+			placeKey:     "@nv5-7c8-b49",
+			wantPentagon: true,
+			wantBaseCell: 97,
+			wantFaces:    []int{18, 13, 8, 12, 17},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewH3()
+			defer c.Close()
+			gotPentagon, err := c.IsPentagon(tt.placeKey)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if gotPentagon != tt.wantPentagon {
+				t.Errorf("IsPentagon() got = %v, want %v", gotPentagon, tt.wantPentagon)
+			}
+			gotBaseCell, err := c.BaseCell(tt.placeKey)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if gotBaseCell != tt.wantBaseCell {
+				t.Errorf("BaseCell() got = %v, want %v", gotBaseCell, tt.wantBaseCell)
+			}
+			gotFaces, err := c.Faces(tt.placeKey)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(gotFaces, tt.wantFaces) {
+				t.Errorf("Faces() got = %v, want %v", gotFaces, tt.wantFaces)
+			}
+		})
+	}
+}
@@ -0,0 +1,342 @@
+package placekey
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"sort"
+	"strconv"
+)
+
+// MVTExtent is the size of the vector tiles in tile units.
+const MVTExtent = 4096
+
+// mvtBuffer is the margin in tile units kept around the vector tiles when
+// clipping, so that the outlines of the cells are not drawn at tile edges.
+const mvtBuffer = 64
+
+// MVTContentType is the media type of Mapbox Vector Tiles.
+const MVTContentType = "application/vnd.mapbox-vector-tile"
+
+var ErrInvalidProperty = errors.New("invalid property")
+
+// MVTLayer is a layer of PlaceKeys of a vector tile. Properties holds the
+// properties of each PlaceKey, of type string, bool, int, int64, uint64,
+// float32 or float64.
+type MVTLayer struct {
+	Name       string
+	Properties map[string]map[string]interface{}
+}
+
+// mvtFeature is a cell of a vector tile layer, in the copy of the world at an
+// offset, with its properties.
+type mvtFeature struct {
+	cell       *tileCell
+	offset     float64
+	properties map[string]interface{}
+}
+
+// EncodeMVT encodes PlaceKeys as the polygons of the layers of the Mapbox
+// Vector Tile at zoom z, column x and row y. The id of each feature is the H3
+// index of its PlaceKey, and the PlaceKeys outside the tile are left out.
+func (c *H3) EncodeMVT(z, x, y int, layers []MVTLayer) ([]byte, error) {
+	if err := checkTile(z, x, y); err != nil {
+		return nil, err
+	}
+	var tile protoBuffer
+	for _, layer := range layers {
+		placeKeys := make([]string, 0, len(layer.Properties))
+		for pk := range layer.Properties {
+			placeKeys = append(placeKeys, pk)
+		}
+		sort.Strings(placeKeys)
+		cells := make([]tileCell, 0, len(placeKeys))
+		for _, pk := range placeKeys {
+			if !FormatIsValid(pk) {
+				return nil, ErrInvalidFormat
+			}
+			h, err := ToH3Index(pk)
+			if err != nil {
+				return nil, err
+			}
+			cells = append(cells, c.tileCell(h, resolution))
+		}
+		positions := make([]int, len(cells))
+		for i := range positions {
+			positions[i] = i
+		}
+		features := []mvtFeature{}
+		visitTileCells(cells, positions, z, x, y, func(i int, offset float64) {
+			features = append(features, mvtFeature{cell: &cells[i], offset: offset, properties: layer.Properties[placeKeys[i]]})
+		})
+		b, err := encodeMVTLayer(layer.Name, features, z, x, y)
+		if err != nil {
+			return nil, err
+		}
+		tile.bytes(3, b)
+	}
+	return tile, nil
+}
+
+// EncodeMVT encodes the tile at zoom z, column x and row y as a Mapbox Vector
+// Tile with a layer named by TileOptions.Layer. Each cell is a polygon whose
+// id is its H3 index, with the properties:
+//
+//	id          the PlaceKey of the cell, or its H3 string below resolution 10
+//	resolution  the resolution of the cell
+//	count, sum  the aggregate of the cell
+//	mean        the mean of the aggregate
+//	value       the value given by TileOptions.Value
+func (t *TileSet) EncodeMVT(z, x, y int) ([]byte, error) {
+	res := t.Resolution(z)
+	features := []mvtFeature{}
+	err := t.tileCells(z, x, y, func(cell *tileCell, offset float64) {
+		id := strconv.FormatUint(uint64(cell.x), 16)
+		if res == resolution {
+			id = encodeH3Int(uint64(cell.x))
+		}
+		features = append(features, mvtFeature{cell: cell, offset: offset, properties: map[string]interface{}{
+			"id":         id,
+			"resolution": res,
+			"count":      cell.agg.Count,
+			"sum":        cell.agg.Sum,
+			"mean":       cell.agg.Mean(),
+			"value":      cell.value,
+		}})
+	})
+	if err != nil {
+		return nil, err
+	}
+	b, err := encodeMVTLayer(t.opts.Layer, features, z, x, y)
+	if err != nil {
+		return nil, err
+	}
+	var tile protoBuffer
+	tile.bytes(3, b)
+	return tile, nil
+}
+
+// encodeMVTLayer encodes a layer of a vector tile, leaving out the features
+// whose polygon vanishes once clipped and quantized.
+func encodeMVTLayer(name string, features []mvtFeature, z, x, y int) ([]byte, error) {
+	var layer protoBuffer
+	layer.uint(15, 2)
+	layer.string(1, name)
+	keys := map[string]int{}
+	keyList := []string{}
+	values := map[string]int{}
+	valueList := [][]byte{}
+	pixels := MVTExtent * math.Exp2(float64(z))
+	for _, f := range features {
+		ring := make([]point, len(f.cell.ring))
+		for i, p := range f.cell.ring {
+			ring[i] = point{
+				x: (p.x+f.offset)*pixels - float64(x*MVTExtent),
+				y: p.y*pixels - float64(y*MVTExtent),
+			}
+		}
+		geometry := mvtPolygon(clipRing(ring, -mvtBuffer, MVTExtent+mvtBuffer))
+		if geometry == nil {
+			continue
+		}
+		names := make([]string, 0, len(f.properties))
+		for k := range f.properties {
+			names = append(names, k)
+		}
+		sort.Strings(names)
+		tags := make([]uint32, 0, 2*len(names))
+		for _, k := range names {
+			value, err := mvtValue(f.properties[k])
+			if err != nil {
+				return nil, err
+			}
+			ki, ok := keys[k]
+			if !ok {
+				ki = len(keyList)
+				keys[k] = ki
+				keyList = append(keyList, k)
+			}
+			vi, ok := values[string(value)]
+			if !ok {
+				vi = len(valueList)
+				values[string(value)] = vi
+				valueList = append(valueList, value)
+			}
+			tags = append(tags, uint32(ki), uint32(vi))
+		}
+		var feature protoBuffer
+		feature.uint(1, uint64(f.cell.x))
+		feature.packed(2, tags)
+		feature.uint(3, 3) // polygon
+		feature.packed(4, geometry)
+		layer.bytes(2, feature)
+	}
+	for _, k := range keyList {
+		layer.string(3, k)
+	}
+	for _, v := range valueList {
+		layer.bytes(4, v)
+	}
+	layer.uint(5, MVTExtent)
+	return layer, nil
+}
+
+// mvtValue encodes a property as a vector tile value.
+func mvtValue(v interface{}) ([]byte, error) {
+	var b protoBuffer
+	switch v := v.(type) {
+	case string:
+		b.string(1, v)
+	case float32:
+		b.key(2, 5)
+		b = append(b, 0, 0, 0, 0)
+		binary.LittleEndian.PutUint32(b[len(b)-4:], math.Float32bits(v))
+	case float64:
+		b.key(3, 1)
+		b = append(b, 0, 0, 0, 0, 0, 0, 0, 0)
+		binary.LittleEndian.PutUint64(b[len(b)-8:], math.Float64bits(v))
+	case int:
+		b.uint(6, zigzag(int64(v)))
+	case int64:
+		b.uint(6, zigzag(v))
+	case uint64:
+		b.uint(5, v)
+	case bool:
+		n := uint64(0)
+		if v {
+			n = 1
+		}
+		b.uint(7, n)
+	default:
+		return nil, ErrInvalidProperty
+	}
+	return b, nil
+}
+
+// clipRing clips a ring to the square from min to max with the
+// Sutherland-Hodgman algorithm.
+func clipRing(ring []point, min, max float64) []point {
+	edges := []struct {
+		inside func(p point) bool
+		cross  func(a, b point) point
+	}{
+		{func(p point) bool { return p.x >= min }, func(a, b point) point {
+			return point{x: min, y: a.y + (min-a.x)*(b.y-a.y)/(b.x-a.x)}
+		}},
+		{func(p point) bool { return p.x <= max }, func(a, b point) point {
+			return point{x: max, y: a.y + (max-a.x)*(b.y-a.y)/(b.x-a.x)}
+		}},
+		{func(p point) bool { return p.y >= min }, func(a, b point) point {
+			return point{x: a.x + (min-a.y)*(b.x-a.x)/(b.y-a.y), y: min}
+		}},
+		{func(p point) bool { return p.y <= max }, func(a, b point) point {
+			return point{x: a.x + (max-a.y)*(b.x-a.x)/(b.y-a.y), y: max}
+		}},
+	}
+	for _, e := range edges {
+		if len(ring) == 0 {
+			return nil
+		}
+		out := make([]point, 0, len(ring)+4)
+		prev := ring[len(ring)-1]
+		for _, p := range ring {
+			switch {
+			case e.inside(p) && e.inside(prev):
+				out = append(out, p)
+			case e.inside(p):
+				out = append(out, e.cross(prev, p), p)
+			case e.inside(prev):
+				out = append(out, e.cross(prev, p))
+			}
+			prev = p
+		}
+		ring = out
+	}
+	return ring
+}
+
+// mvtPolygon quantizes a ring and encodes it as the geometry of a vector tile
+// polygon, clockwise on screen, or returns nil when it has no area.
+func mvtPolygon(ring []point) []uint32 {
+	pts := make([][2]int64, 0, len(ring))
+	for _, p := range ring {
+		q := [2]int64{int64(math.Round(p.x)), int64(math.Round(p.y))}
+		if len(pts) == 0 || q != pts[len(pts)-1] {
+			pts = append(pts, q)
+		}
+	}
+	for len(pts) > 1 && pts[0] == pts[len(pts)-1] {
+		pts = pts[:len(pts)-1]
+	}
+	if len(pts) < 3 {
+		return nil
+	}
+	area := int64(0)
+	for i := range pts {
+		a, b := pts[i], pts[(i+1)%len(pts)]
+		area += a[0]*b[1] - b[0]*a[1]
+	}
+	if area == 0 {
+		return nil
+	}
+	if area < 0 {
+		for i, j := 0, len(pts)-1; i < j; i, j = i+1, j-1 {
+			pts[i], pts[j] = pts[j], pts[i]
+		}
+	}
+	command := func(id, count int) uint32 { return uint32(id&7) | uint32(count)<<3 }
+	geometry := make([]uint32, 0, 2*len(pts)+3)
+	var cx, cy int64
+	for i, p := range pts {
+		switch i {
+		case 0:
+			geometry = append(geometry, command(1, 1)) // MoveTo
+		case 1:
+			geometry = append(geometry, command(2, len(pts)-1)) // LineTo
+		}
+		geometry = append(geometry, uint32(zigzag(p[0]-cx)), uint32(zigzag(p[1]-cy)))
+		cx, cy = p[0], p[1]
+	}
+	return append(geometry, command(7, 1)) // ClosePath
+}
+
+// zigzag encodes a signed integer so that small magnitudes have small
+// encodings.
+func zigzag(n int64) uint64 {
+	return uint64((n << 1) ^ (n >> 63))
+}
+
+// protoBuffer is a protocol buffers message being written.
+type protoBuffer []byte
+
+func (b *protoBuffer) varint(v uint64) {
+	var buf [binary.MaxVarintLen64]byte
+	*b = append(*b, buf[:binary.PutUvarint(buf[:], v)]...)
+}
+
+func (b *protoBuffer) key(field, wireType int) {
+	b.varint(uint64(field)<<3 | uint64(wireType))
+}
+
+func (b *protoBuffer) uint(field int, v uint64) {
+	b.key(field, 0)
+	b.varint(v)
+}
+
+func (b *protoBuffer) bytes(field int, p []byte) {
+	b.key(field, 2)
+	b.varint(uint64(len(p)))
+	*b = append(*b, p...)
+}
+
+func (b *protoBuffer) string(field int, s string) {
+	b.bytes(field, []byte(s))
+}
+
+func (b *protoBuffer) packed(field int, vs []uint32) {
+	var p protoBuffer
+	for _, v := range vs {
+		p.varint(uint64(v))
+	}
+	b.bytes(field, p)
+}
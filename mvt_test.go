@@ -0,0 +1,378 @@
+package placekey
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+// testMVTFeature is a decoded vector tile feature.
+type testMVTFeature struct {
+	id         uint64
+	kind       uint64
+	properties map[string]interface{}
+	rings      [][][2]int64
+}
+
+// testMVTLayer is a decoded vector tile layer.
+type testMVTLayer struct {
+	name     string
+	version  uint64
+	extent   uint64
+	features []testMVTFeature
+}
+
+// testProtoFields decodes the fields of a protocol buffers message, varints
+// and fixed values as uint64 and length delimited values as []byte.
+func testProtoFields(t *testing.T, b []byte) (fields []int, values []interface{}) {
+	t.Helper()
+	for len(b) > 0 {
+		key, n := binary.Uvarint(b)
+		if n <= 0 {
+			t.Fatal("invalid key")
+		}
+		b = b[n:]
+		var v interface{}
+		switch key & 7 {
+		case 0:
+			u, n := binary.Uvarint(b)
+			if n <= 0 {
+				t.Fatal("invalid varint")
+			}
+			v, b = u, b[n:]
+		case 1:
+			v, b = binary.LittleEndian.Uint64(b), b[8:]
+		case 2:
+			l, n := binary.Uvarint(b)
+			if n <= 0 || int(l) > len(b)-n {
+				t.Fatal("invalid length")
+			}
+			v, b = b[n:n+int(l)], b[n+int(l):]
+		case 5:
+			v, b = uint64(binary.LittleEndian.Uint32(b)), b[4:]
+		default:
+			t.Fatalf("invalid wire type %d", key&7)
+		}
+		fields = append(fields, int(key>>3))
+		values = append(values, v)
+	}
+	return fields, values
+}
+
+func testPacked(t *testing.T, b []byte) []uint32 {
+	t.Helper()
+	vs := []uint32{}
+	for len(b) > 0 {
+		v, n := binary.Uvarint(b)
+		if n <= 0 {
+			t.Fatal("invalid packed varint")
+		}
+		vs, b = append(vs, uint32(v)), b[n:]
+	}
+	return vs
+}
+
+// testDecodeMVT decodes a vector tile.
+func testDecodeMVT(t *testing.T, b []byte) []testMVTLayer {
+	t.Helper()
+	layers := []testMVTLayer{}
+	fields, values := testProtoFields(t, b)
+	for i, field := range fields {
+		if field != 3 {
+			t.Fatalf("unexpected tile field %d", field)
+		}
+		layer := testMVTLayer{extent: 4096}
+		var keys []string
+		var vals []interface{}
+		var features [][]byte
+		lf, lv := testProtoFields(t, values[i].([]byte))
+		for j, f := range lf {
+			switch f {
+			case 1:
+				layer.name = string(lv[j].([]byte))
+			case 2:
+				features = append(features, lv[j].([]byte))
+			case 3:
+				keys = append(keys, string(lv[j].([]byte)))
+			case 4:
+				vf, vv := testProtoFields(t, lv[j].([]byte))
+				if len(vf) != 1 {
+					t.Fatal("invalid value")
+				}
+				switch vf[0] {
+				case 1:
+					vals = append(vals, string(vv[0].([]byte)))
+				case 2:
+					vals = append(vals, math.Float32frombits(uint32(vv[0].(uint64))))
+				case 3:
+					vals = append(vals, math.Float64frombits(vv[0].(uint64)))
+				case 5:
+					vals = append(vals, vv[0].(uint64))
+				case 6:
+					u := vv[0].(uint64)
+					vals = append(vals, int64(u>>1)^-int64(u&1))
+				case 7:
+					vals = append(vals, vv[0].(uint64) == 1)
+				default:
+					t.Fatalf("unexpected value field %d", vf[0])
+				}
+			case 5:
+				layer.extent = lv[j].(uint64)
+			case 15:
+				layer.version = lv[j].(uint64)
+			}
+		}
+		for _, fb := range features {
+			feature := testMVTFeature{properties: map[string]interface{}{}}
+			ff, fv := testProtoFields(t, fb)
+			for j, f := range ff {
+				switch f {
+				case 1:
+					feature.id = fv[j].(uint64)
+				case 2:
+					tags := testPacked(t, fv[j].([]byte))
+					for k := 0; k+1 < len(tags); k += 2 {
+						feature.properties[keys[tags[k]]] = vals[tags[k+1]]
+					}
+				case 3:
+					feature.kind = fv[j].(uint64)
+				case 4:
+					feature.rings = testDecodeGeometry(t, testPacked(t, fv[j].([]byte)))
+				}
+			}
+			layer.features = append(layer.features, feature)
+		}
+		layers = append(layers, layer)
+	}
+	return layers
+}
+
+func testDecodeGeometry(t *testing.T, geometry []uint32) [][][2]int64 {
+	t.Helper()
+	rings := [][][2]int64{}
+	var ring [][2]int64
+	var x, y int64
+	unzigzag := func(u uint32) int64 { return int64(u>>1) ^ -int64(u&1) }
+	for i := 0; i < len(geometry); {
+		id, count := geometry[i]&7, int(geometry[i]>>3)
+		i++
+		switch id {
+		case 1, 2:
+			if id == 1 {
+				ring = nil
+			}
+			for k := 0; k < count; k++ {
+				x += unzigzag(geometry[i])
+				y += unzigzag(geometry[i+1])
+				i += 2
+				ring = append(ring, [2]int64{x, y})
+			}
+		case 7:
+			rings = append(rings, ring)
+		default:
+			t.Fatalf("invalid command %d", id)
+		}
+	}
+	return rings
+}
+
+func testRingArea(ring [][2]int64) int64 {
+	area := int64(0)
+	for i := range ring {
+		a, b := ring[i], ring[(i+1)%len(ring)]
+		area += a[0]*b[1] - b[0]*a[1]
+	}
+	return area
+}
+
+func TestZigzag(t *testing.T) {
+	tests := []struct {
+		n    int64
+		want uint64
+	}{
+		{0, 0}, {-1, 1}, {1, 2}, {-2, 3}, {2147483647, 4294967294}, {-2147483648, 4294967295},
+	}
+	for _, tt := range tests {
+		if got := zigzag(tt.n); got != tt.want {
+			t.Errorf("zigzag(%d) got = %v, want %v", tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestClipRing(t *testing.T) {
+	square := []point{{-10, -10}, {10, -10}, {10, 10}, {-10, 10}}
+	got := clipRing(square, 0, 100)
+	want := []point{{0, 0}, {10, 0}, {10, 10}, {0, 10}}
+	if len(got) != len(want) {
+		t.Fatalf("clipRing() got = %v", got)
+	}
+	area := 0.0
+	for i := range got {
+		a, b := got[i], got[(i+1)%len(got)]
+		area += a.x*b.y - b.x*a.y
+		if a.x < 0 || a.y < 0 {
+			t.Errorf("clipRing() got point %v outside", a)
+		}
+	}
+	if math.Abs(area/2) != 100 {
+		t.Errorf("clipRing() got area %v", area/2)
+	}
+	if got := clipRing(square, 20, 100); len(got) != 0 {
+		t.Errorf("clipRing() got = %v, want none", got)
+	}
+}
+
+func TestMVTPolygon(t *testing.T) {
+	// counterclockwise on screen, reversed
+	geometry := mvtPolygon([]point{{0, 0}, {0, 10}, {10, 10}, {10, 0}})
+	want := []uint32{9, 20, 0, 26, 0, 20, 19, 0, 0, 19, 15}
+	if !reflect.DeepEqual(geometry, want) {
+		t.Errorf("mvtPolygon() got = %v, want %v", geometry, want)
+	}
+	rings := testDecodeGeometry(t, geometry)
+	if len(rings) != 1 || testRingArea(rings[0]) <= 0 {
+		t.Errorf("mvtPolygon() got rings %v", rings)
+	}
+	if got := mvtPolygon([]point{{0, 0}, {0.2, 0.1}, {0.1, 0.3}}); got != nil {
+		t.Errorf("mvtPolygon() of a vanishing ring got = %v", got)
+	}
+}
+
+func TestH3_EncodeMVT(t *testing.T) {
+	c := NewH3()
+	defer c.Close()
+	lat, lng, err := c.ToGeo("@5vg-7gq-tvz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	z := 16
+	x, y, _, _ := testTile(z, lat, lng)
+	layers := []MVTLayer{
+		{Name: "pois", Properties: map[string]map[string]interface{}{
+			"@5vg-7gq-tvz": {"name": "City Hall", "visits": 12, "open": true, "score": 0.5, "rank": float32(1.5), "big": uint64(1 << 40), "delta": int64(-3)},
+			"@5vg-82n-kzz": {"name": "far away"},
+		}},
+		{Name: "empty"},
+	}
+	b, err := c.EncodeMVT(z, x, y, layers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := testDecodeMVT(t, b)
+	if len(got) != 2 || got[0].name != "pois" || got[1].name != "empty" {
+		t.Fatalf("EncodeMVT() got layers %+v", got)
+	}
+	if got[0].version != 2 || got[0].extent != MVTExtent || len(got[0].features) != 1 || len(got[1].features) != 0 {
+		t.Fatalf("EncodeMVT() got = %+v", got)
+	}
+	f := got[0].features[0]
+	h, err := ToH3Index("@5vg-7gq-tvz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"name": "City Hall", "visits": int64(12), "open": true, "score": 0.5, "rank": float32(1.5), "big": uint64(1 << 40), "delta": int64(-3),
+	}
+	if f.id != uint64(h) || f.kind != 3 || !reflect.DeepEqual(f.properties, want) {
+		t.Errorf("EncodeMVT() got feature %+v", f)
+	}
+	if len(f.rings) != 1 || len(f.rings[0]) != 6 || testRingArea(f.rings[0]) <= 0 {
+		t.Errorf("EncodeMVT() got rings %v", f.rings)
+	}
+	for _, p := range f.rings[0] {
+		if p[0] < -mvtBuffer || p[0] > MVTExtent+mvtBuffer || p[1] < -mvtBuffer || p[1] > MVTExtent+mvtBuffer {
+			t.Errorf("EncodeMVT() got point %v outside", p)
+		}
+	}
+
+	if _, err := c.EncodeMVT(z, x, y, []MVTLayer{{Name: "bad", Properties: map[string]map[string]interface{}{
+		"@5vg-7gq-tvz": {"nested": []int{1}},
+	}}}); !errors.Is(err, ErrInvalidProperty) {
+		t.Errorf("EncodeMVT() error = %v", err)
+	}
+	if _, err := c.EncodeMVT(z, x, y, []MVTLayer{{Name: "bad", Properties: map[string]map[string]interface{}{"invalid": nil}}}); !errors.Is(err, ErrInvalidFormat) {
+		t.Errorf("EncodeMVT() error = %v", err)
+	}
+	if _, err := c.EncodeMVT(1, 2, 0, nil); !errors.Is(err, ErrInvalidTile) {
+		t.Errorf("EncodeMVT() error = %v", err)
+	}
+}
+
+func TestTileSet_EncodeMVT(t *testing.T) {
+	c := NewH3()
+	defer c.Close()
+	a := NewAggregator()
+	for _, v := range []struct {
+		placeKey string
+		value    float64
+	}{{"@5vg-7gq-tvz", 2}, {"@5vg-7gq-tvz", 4}, {"@5vg-7gt-qzz", 1}} {
+		if err := a.Add(v.placeKey, v.value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ts, err := c.NewTileSet(a, TileOptions{Layer: "visits"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	lat, lng, err := c.ToGeo("@5vg-7gq-tvz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	x, y, _, _ := testTile(14, lat, lng)
+	b, err := ts.EncodeMVT(14, x, y)
+	if err != nil {
+		t.Fatal(err)
+	}
+	layers := testDecodeMVT(t, b)
+	if len(layers) != 1 || layers[0].name != "visits" {
+		t.Fatalf("EncodeMVT() got = %+v", layers)
+	}
+	var found bool
+	for _, f := range layers[0].features {
+		if f.properties["id"] != "@5vg-7gq-tvz" {
+			continue
+		}
+		found = true
+		want := map[string]interface{}{
+			"id": "@5vg-7gq-tvz", "resolution": int64(10), "count": int64(2), "sum": 6.0, "mean": 3.0, "value": 2.0,
+		}
+		if !reflect.DeepEqual(f.properties, want) {
+			t.Errorf("EncodeMVT() got properties %v, want %v", f.properties, want)
+		}
+	}
+	if !found {
+		t.Errorf("EncodeMVT() got no @5vg-7gq-tvz feature in %+v", layers[0].features)
+	}
+
+	// a whole world tile holds the resolution 0 parent
+	b, err = ts.EncodeMVT(0, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	layers = testDecodeMVT(t, b)
+	if len(layers[0].features) != 1 || layers[0].features[0].properties["resolution"] != int64(0) || layers[0].features[0].properties["count"] != int64(3) {
+		t.Errorf("EncodeMVT(0, 0, 0) got = %+v", layers)
+	}
+
+	srv := httptest.NewServer(ts)
+	defer srv.Close()
+	for _, ext := range []string{".mvt", ".pbf"} {
+		res, err := http.Get(srv.URL + "/tiles/0/0/0" + ext)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, err := io.ReadAll(res.Body)
+		res.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.StatusCode != http.StatusOK || res.Header.Get("Content-Type") != MVTContentType || !reflect.DeepEqual(body, b) {
+			t.Errorf("GET %s got status %v, content type %q", ext, res.StatusCode, res.Header.Get("Content-Type"))
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package placekey
+
+import (
+	"runtime"
+	"sync"
+)
+
+// minChunk is the smallest number of items worth handing to a worker.
+const minChunk int = 256
+
+// contextPool holds the H3 contexts of the workers of parallel between calls.
+// The contexts dropped by the pool are closed when garbage collected.
+var contextPool = sync.Pool{
+	New: func() interface{} {
+		c := NewH3()
+		runtime.SetFinalizer(c, (*H3).Close)
+		return c
+	},
+}
+
+// parallel splits the range [0, n) into chunks processed concurrently by fn.
+// Each worker gets its own H3 context, the first one reusing c and the others
+// taken from contextPool, as contexts must not be shared between goroutines.
+// The first error returned by a worker is returned.
+func (c *H3) parallel(n int, fn func(c *H3, lo, hi int) error) error {
+	workers := runtime.GOMAXPROCS(0)
+	if max := (n + minChunk - 1) / minChunk; workers > max {
+		workers = max
+	}
+	if workers <= 1 {
+		return fn(c, 0, n)
+	}
+	chunk := (n + workers - 1) / workers
+	errs := make([]error, workers)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		lo, hi := w*chunk, (w+1)*chunk
+		if hi > n {
+			hi = n
+		}
+		wg.Add(1)
+		go func(w, lo, hi int) {
+			defer wg.Done()
+			wc := c
+			if w > 0 {
+				wc = contextPool.Get().(*H3)
+				defer contextPool.Put(wc)
+			}
+			errs[w] = fn(wc, lo, hi)
+		}(w, lo, hi)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
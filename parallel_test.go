@@ -0,0 +1,51 @@
+package placekey
+
+import (
+	"errors"
+	"runtime"
+	"sync"
+	"testing"
+)
+
+func TestH3_parallel(t *testing.T) {
+	c := NewH3()
+	defer c.Close()
+	for _, n := range []int{0, 1, minChunk, 10 * minChunk} {
+		seen := make([]int, n)
+		var mu sync.Mutex
+		contexts := map[*H3]bool{}
+		err := c.parallel(n, func(wc *H3, lo, hi int) error {
+			mu.Lock()
+			defer mu.Unlock()
+			if contexts[wc] {
+				t.Errorf("parallel(%d) shared a context between workers", n)
+			}
+			contexts[wc] = true
+			for i := lo; i < hi; i++ {
+				seen[i]++
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, count := range seen {
+			if count != 1 {
+				t.Fatalf("parallel(%d) processed item %d %d times", n, i, count)
+			}
+		}
+		if !contexts[c] {
+			t.Errorf("parallel(%d) did not reuse the calling context", n)
+		}
+	}
+	want := errors.New("failed")
+	err := c.parallel(10*minChunk, func(_ *H3, lo, _ int) error {
+		if lo > 0 {
+			return want
+		}
+		return nil
+	})
+	if runtime.GOMAXPROCS(0) > 1 && !errors.Is(err, want) {
+		t.Errorf("parallel() error = %v", err)
+	}
+}
@@ -27,6 +27,7 @@ const (
 
 var ErrInvalidResolution = errors.New("invalid resolution")
 var ErrInvalidParts = errors.New("invalid parts")
+var ErrInvalidFormat = errors.New("invalid format")
 
 var (
 	fixHeaderInt   uint64
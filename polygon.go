@@ -0,0 +1,101 @@
+package placekey
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/diegosz/placekey-go/internal/h3"
+)
+
+// GeoCoord is a (latitude, longitude) coordinate in degrees.
+type GeoCoord = h3.GeoCoord
+
+// GeoPolygon is a polygon made of an outer boundary, the geofence, and zero or
+// more holes. Loops are not closed: the last coordinate is not a repetition of
+// the first one.
+type GeoPolygon = h3.GeoPolygon
+
+// MultiPolygon is a set of disjoint polygons.
+type MultiPolygon []GeoPolygon
+
+// SetToMultiPolygon dissolves a set of PlaceKeys into the polygons outlining
+// them, with holes where the set surrounds cells not in it. Duplicated
+// PlaceKeys are ignored and the what part of the PlaceKeys is dropped.
+func (c *H3) SetToMultiPolygon(placeKeys []string) (MultiPolygon, error) {
+	seen := map[h3.Index]bool{}
+	hs := make([]h3.Index, 0, len(placeKeys))
+	for _, pk := range placeKeys {
+		x, err := ToH3Index(pk)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[x] {
+			seen[x] = true
+			hs = append(hs, x)
+		}
+	}
+	return MultiPolygon(c.h3.SetToMultiPolygon(hs)), nil
+}
+
+// GeoJSON returns the MultiPolygon as a GeoJSON geometry object.
+func (m MultiPolygon) GeoJSON() ([]byte, error) {
+	coordinates := make([][][][]float64, 0, len(m))
+	for _, p := range m {
+		rings := [][][]float64{geoJSONRing(p.Geofence)}
+		for _, h := range p.Holes {
+			rings = append(rings, geoJSONRing(h))
+		}
+		coordinates = append(coordinates, rings)
+	}
+	return json.Marshal(struct {
+		Type        string          `json:"type"`
+		Coordinates [][][][]float64 `json:"coordinates"`
+	}{
+		Type:        "MultiPolygon",
+		Coordinates: coordinates,
+	})
+}
+
+// WKT returns the MultiPolygon in the Well-Known Text format.
+func (m MultiPolygon) WKT() string {
+	if len(m) == 0 {
+		return "MULTIPOLYGON EMPTY"
+	}
+	polygons := make([]string, 0, len(m))
+	for _, p := range m {
+		rings := []string{wktRing(p.Geofence)}
+		for _, h := range p.Holes {
+			rings = append(rings, wktRing(h))
+		}
+		polygons = append(polygons, "("+strings.Join(rings, ", ")+")")
+	}
+	return "MULTIPOLYGON (" + strings.Join(polygons, ", ") + ")"
+}
+
+// geoJSONRing returns a closed ring of (longitude, latitude) positions.
+func geoJSONRing(loop []GeoCoord) [][]float64 {
+	ring := make([][]float64, 0, len(loop)+1)
+	for _, g := range loop {
+		ring = append(ring, []float64{g.Longitude, g.Latitude})
+	}
+	if len(loop) > 0 {
+		ring = append(ring, []float64{loop[0].Longitude, loop[0].Latitude})
+	}
+	return ring
+}
+
+func wktRing(loop []GeoCoord) string {
+	points := make([]string, 0, len(loop)+1)
+	for _, g := range loop {
+		points = append(points, formatFloat(g.Longitude)+" "+formatFloat(g.Latitude))
+	}
+	if len(loop) > 0 {
+		points = append(points, points[0])
+	}
+	return "(" + strings.Join(points, ", ") + ")"
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
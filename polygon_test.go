@@ -0,0 +1,143 @@
+package placekey
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestH3_SetToMultiPolygon(t *testing.T) {
+	c := NewH3()
+	defer c.Close()
+	center, err := ToH3Index("@5vg-7gq-tvz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	disk := []string{}
+	ring := []string{}
+	for _, x := range c.h3.KRing(center, 1) {
+		pk := encodeH3Int(uint64(x))
+		disk = append(disk, pk)
+		if x != center {
+			ring = append(ring, pk)
+		}
+	}
+	tests := []struct {
+		name         string
+		placeKeys    []string
+		wantPolygons int
+		wantVertices int
+		wantHoles    int
+	}{
+		{
+			name:         "empty",
+			placeKeys:    nil,
+			wantPolygons: 0,
+		},
+		{
+			name:         "single",
+			placeKeys:    []string{"@5vg-7gq-tvz", "zzw-22y@5vg-7gq-tvz"},
+			wantPolygons: 1,
+			wantVertices: 6,
+			wantHoles:    0,
+		},
+		{
+			name:         "disk",
+			placeKeys:    disk,
+			wantPolygons: 1,
+			wantVertices: 18,
+			wantHoles:    0,
+		},
+		{
+			name:         "ring",
+			placeKeys:    ring,
+			wantPolygons: 1,
+			wantVertices: 18,
+			wantHoles:    1,
+		},
+		{
+			name:         "disjoint",
+			placeKeys:    []string{"@5vg-7gq-tvz", "@5vg-7gt-qzz"},
+			wantPolygons: 2,
+			wantVertices: 6,
+			wantHoles:    0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := c.SetToMultiPolygon(tt.placeKeys)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != tt.wantPolygons {
+				t.Fatalf("SetToMultiPolygon() got %d polygons, want %d", len(got), tt.wantPolygons)
+			}
+			for _, p := range got {
+				if len(p.Geofence) != tt.wantVertices {
+					t.Errorf("SetToMultiPolygon() got %d vertices, want %d", len(p.Geofence), tt.wantVertices)
+				}
+				if len(p.Holes) != tt.wantHoles {
+					t.Errorf("SetToMultiPolygon() got %d holes, want %d", len(p.Holes), tt.wantHoles)
+				}
+			}
+		})
+	}
+}
+
+func TestMultiPolygon_GeoJSON(t *testing.T) {
+	m := MultiPolygon{
+		{
+			Geofence: []GeoCoord{{Latitude: 0, Longitude: 0}, {Latitude: 0, Longitude: 1}, {Latitude: 1, Longitude: 1}},
+			Holes:    [][]GeoCoord{{{Latitude: 0.2, Longitude: 0.5}, {Latitude: 0.4, Longitude: 0.7}, {Latitude: 0.4, Longitude: 0.5}}},
+		},
+	}
+	got, err := m.GeoJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"type":"MultiPolygon","coordinates":[[[[0,0],[1,0],[1,1],[0,0]],[[0.5,0.2],[0.7,0.4],[0.5,0.4],[0.5,0.2]]]]}`
+	if string(got) != want {
+		t.Errorf("GeoJSON() got = %s, want %s", got, want)
+	}
+	var v map[string]interface{}
+	if err := json.Unmarshal(got, &v); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMultiPolygon_WKT(t *testing.T) {
+	tests := []struct {
+		name string
+		m    MultiPolygon
+		want string
+	}{
+		{
+			name: "empty",
+			m:    nil,
+			want: "MULTIPOLYGON EMPTY",
+		},
+		{
+			name: "triangle",
+			m: MultiPolygon{
+				{Geofence: []GeoCoord{{Latitude: 0, Longitude: 0}, {Latitude: 0, Longitude: 1}, {Latitude: 1, Longitude: 1}}},
+			},
+			want: "MULTIPOLYGON (((0 0, 1 0, 1 1, 0 0)))",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.m.WKT(); got != tt.want {
+				t.Errorf("WKT() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+	c := NewH3()
+	defer c.Close()
+	m, err := c.SetToMultiPolygon([]string{"@5vg-7gq-tvz"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := m.WKT(); !strings.HasPrefix(got, "MULTIPOLYGON (((-122.41") {
+		t.Errorf("WKT() got = %v", got)
+	}
+}
@@ -0,0 +1,325 @@
+//nolint:gomnd
+package placekey
+
+import (
+	crand "crypto/rand"
+	"encoding/binary"
+	"errors"
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/diegosz/placekey-go/internal/h3"
+)
+
+// Mechanism is the noise distribution of a differentially private release.
+type Mechanism int
+
+const (
+	// MechanismLaplace adds Laplace noise, giving pure epsilon-DP.
+	MechanismLaplace Mechanism = iota
+	// MechanismGaussian adds Gaussian noise, giving (epsilon, delta)-DP for
+	// epsilon up to 1.
+	MechanismGaussian
+)
+
+var ErrInvalidMechanism = errors.New("invalid mechanism")
+var ErrInvalidEpsilon = errors.New("invalid epsilon")
+var ErrInvalidDelta = errors.New("invalid delta")
+var ErrInvalidSensitivity = errors.New("invalid sensitivity")
+var ErrBudgetExceeded = errors.New("privacy budget exceeded")
+var ErrMissingDomain = errors.New("missing domain")
+
+// PrivacyAccountant tracks the privacy budget spent by the releases of a
+// dataset, under sequential composition: the epsilons and deltas of the
+// releases add up. A PrivacyAccountant is safe for concurrent use.
+type PrivacyAccountant struct {
+	mu                       sync.Mutex
+	epsilon, delta           float64
+	spentEpsilon, spentDelta float64
+}
+
+// NewPrivacyAccountant returns a PrivacyAccountant with a total budget.
+func NewPrivacyAccountant(epsilon, delta float64) (*PrivacyAccountant, error) {
+	if !(epsilon > 0) || math.IsInf(epsilon, 0) {
+		return nil, ErrInvalidEpsilon
+	}
+	if !(delta >= 0 && delta < 1) {
+		return nil, ErrInvalidDelta
+	}
+	return &PrivacyAccountant{epsilon: epsilon, delta: delta}, nil
+}
+
+// Spend records the use of a part of the budget, or returns
+// ErrBudgetExceeded, spending nothing, when not enough is left.
+func (a *PrivacyAccountant) Spend(epsilon, delta float64) error {
+	if !(epsilon > 0) || math.IsInf(epsilon, 0) {
+		return ErrInvalidEpsilon
+	}
+	if !(delta >= 0 && delta < 1) {
+		return ErrInvalidDelta
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	// tolerate the rounding of budgets split in equal parts
+	const tolerance = 1e-12
+	if a.spentEpsilon+epsilon > a.epsilon*(1+tolerance) || a.spentDelta+delta > a.delta*(1+tolerance) {
+		return ErrBudgetExceeded
+	}
+	a.spentEpsilon += epsilon
+	a.spentDelta += delta
+	return nil
+}
+
+// Spent returns the budget spent so far.
+func (a *PrivacyAccountant) Spent() (epsilon, delta float64) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.spentEpsilon, a.spentDelta
+}
+
+// Remaining returns the budget left.
+func (a *PrivacyAccountant) Remaining() (epsilon, delta float64) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return math.Max(0, a.epsilon-a.spentEpsilon), math.Max(0, a.delta-a.spentDelta)
+}
+
+// PrivacyOptions configures a differentially private release.
+type PrivacyOptions struct {
+	Mechanism Mechanism
+	// Epsilon and Delta are spent by the release, Delta only with
+	// MechanismGaussian. They are split evenly across the released levels.
+	Epsilon, Delta float64
+	// Sensitivity is the largest count a single individual adds to the
+	// aggregates, 1 when zero.
+	Sensitivity float64
+	// Resolutions are the coarser H3 resolutions, from 0 to 9, released along
+	// with the PlaceKeys.
+	Resolutions []int
+	// Domain is the public set of PlaceKeys to release, zero counts included,
+	// and is required: releasing the PlaceKeys that have counts would reveal
+	// them. Counts outside the domain are dropped.
+	Domain []string
+	// Rand is the source of the noise, crypto/rand when nil. The noise of a
+	// seeded source can be predicted, which voids the privacy guarantee, so
+	// it is only meant for reproducible tests.
+	Rand *rand.Rand
+}
+
+// PrivateCounts are noisy counts by PlaceKey and by parent cell, consistent
+// across levels: the count of a cell is the sum of the counts of its children
+// at the next released level. Noisy counts are fractional and may be negative.
+type PrivateCounts struct {
+	PlaceKeys map[string]float64
+	// Rollups are the counts by H3 string for each released resolution, as
+	// returned by Aggregator.Rollup.
+	Rollups map[int]map[string]float64
+	Epsilon float64
+	Delta   float64
+}
+
+// privateNode is a cell of the released hierarchy.
+type privateNode struct {
+	noisy, estimate, variance float64
+	children                  []h3.Index
+}
+
+// Release releases the counts of an Aggregator with differential privacy,
+// spending the budget of the release from the accountant first.
+//
+// Every level gets independent noise calibrated to its share of the budget,
+// then the levels are made consistent by inverse-variance weighting, bottom up,
+// and by spreading the difference between each cell and the sum of its
+// children over the children, top down. Consistency is post-processing and
+// does not spend budget.
+func (a *PrivacyAccountant) Release(c *H3, agg *Aggregator, opts PrivacyOptions) (PrivateCounts, error) {
+	if opts.Mechanism < MechanismLaplace || opts.Mechanism > MechanismGaussian {
+		return PrivateCounts{}, ErrInvalidMechanism
+	}
+	if opts.Mechanism == MechanismLaplace && opts.Delta != 0 {
+		return PrivateCounts{}, ErrInvalidDelta
+	}
+	if opts.Mechanism == MechanismGaussian && !(opts.Delta > 0 && opts.Delta < 1) {
+		return PrivateCounts{}, ErrInvalidDelta
+	}
+	if opts.Mechanism == MechanismGaussian && opts.Epsilon > 1 {
+		return PrivateCounts{}, ErrInvalidEpsilon
+	}
+	if opts.Sensitivity < 0 || math.IsNaN(opts.Sensitivity) || math.IsInf(opts.Sensitivity, 0) {
+		return PrivateCounts{}, ErrInvalidSensitivity
+	}
+	if opts.Sensitivity == 0 {
+		opts.Sensitivity = 1
+	}
+	levels := []int{resolution}
+	seen := map[int]bool{resolution: true}
+	for _, res := range opts.Resolutions {
+		if res < 0 || res >= resolution {
+			return PrivateCounts{}, ErrInvalidResolution
+		}
+		if !seen[res] {
+			seen[res] = true
+			levels = append(levels, res)
+		}
+	}
+	// finest level first
+	sort.Sort(sort.Reverse(sort.IntSlice(levels)))
+
+	if opts.Domain == nil {
+		return PrivateCounts{}, ErrMissingDomain
+	}
+	leaves := map[h3.Index]bool{}
+	for _, pk := range opts.Domain {
+		x, err := ToH3Index(pk)
+		if err != nil {
+			return PrivateCounts{}, err
+		}
+		leaves[x] = true
+	}
+	cells := agg.snapshot()
+	if err := a.Spend(opts.Epsilon, opts.Delta); err != nil {
+		return PrivateCounts{}, err
+	}
+	r := opts.Rand
+	if r == nil {
+		r = rand.New(cryptoSource{})
+	}
+	epsilon := opts.Epsilon / float64(len(levels))
+	delta := opts.Delta / float64(len(levels))
+	noise, variance := laplaceNoise(r, opts.Sensitivity/epsilon)
+	if opts.Mechanism == MechanismGaussian {
+		noise, variance = gaussianNoise(r, opts.Sensitivity*math.Sqrt(2*math.Log(1.25/delta))/epsilon)
+	}
+
+	// build the hierarchy, level by level, with the true counts
+	nodes := make([]map[h3.Index]*privateNode, len(levels))
+	nodes[0] = map[h3.Index]*privateNode{}
+	for x := range leaves {
+		nodes[0][x] = &privateNode{noisy: float64(cells[x].Count)}
+	}
+	for l := 1; l < len(levels); l++ {
+		nodes[l] = map[h3.Index]*privateNode{}
+		for x, child := range nodes[l-1] {
+			p := c.h3.ToParent(x, levels[l])
+			parent := nodes[l][p]
+			if parent == nil {
+				parent = &privateNode{}
+				nodes[l][p] = parent
+			}
+			parent.noisy += child.noisy
+			parent.children = append(parent.children, x)
+		}
+		for _, n := range nodes[l] {
+			sort.Slice(n.children, func(i, j int) bool { return n.children[i] < n.children[j] })
+		}
+	}
+	// add the noise in a deterministic order, so that a seeded source gives
+	// reproducible releases
+	for l := range levels {
+		for _, x := range sortedIndexes(nodes[l]) {
+			nodes[l][x].noisy += noise()
+		}
+	}
+
+	// bottom up, combine the noisy count of each cell with the sum of the
+	// estimates of its children
+	for l := range levels {
+		for _, n := range nodes[l] {
+			if l == 0 {
+				n.estimate, n.variance = n.noisy, variance
+				continue
+			}
+			sum, sumVariance := 0.0, 0.0
+			for _, x := range n.children {
+				sum += nodes[l-1][x].estimate
+				sumVariance += nodes[l-1][x].variance
+			}
+			n.estimate = (sumVariance*n.noisy + variance*sum) / (variance + sumVariance)
+			n.variance = variance * sumVariance / (variance + sumVariance)
+		}
+	}
+	// top down, spread the difference between each cell and its children
+	for l := len(levels) - 1; l > 0; l-- {
+		for _, n := range nodes[l] {
+			sum, sumVariance := 0.0, 0.0
+			for _, x := range n.children {
+				sum += nodes[l-1][x].estimate
+				sumVariance += nodes[l-1][x].variance
+			}
+			for _, x := range n.children {
+				child := nodes[l-1][x]
+				child.estimate += (n.estimate - sum) * child.variance / sumVariance
+			}
+		}
+	}
+
+	out := PrivateCounts{
+		PlaceKeys: make(map[string]float64, len(nodes[0])),
+		Rollups:   map[int]map[string]float64{},
+		Epsilon:   opts.Epsilon,
+		Delta:     opts.Delta,
+	}
+	for x, n := range nodes[0] {
+		out.PlaceKeys[encodeH3Int(uint64(x))] = n.estimate
+	}
+	for l := 1; l < len(levels); l++ {
+		counts := make(map[string]float64, len(nodes[l]))
+		for x, n := range nodes[l] {
+			counts[strconv.FormatUint(uint64(x), 16)] = n.estimate
+		}
+		out.Rollups[levels[l]] = counts
+	}
+	return out, nil
+}
+
+// cryptoSource is a rand.Source reading crypto/rand, whose output cannot be
+// predicted from earlier outputs, unlike the generator of math/rand.
+type cryptoSource struct{}
+
+func (cryptoSource) Uint64() uint64 {
+	var b [8]byte
+	if _, err := crand.Read(b[:]); err != nil {
+		// crypto/rand only fails when the system has no entropy source, and
+		// noise must not fall back on a predictable one
+		panic(err)
+	}
+	return binary.LittleEndian.Uint64(b[:])
+}
+
+func (s cryptoSource) Int63() int64 {
+	return int64(s.Uint64() >> 1)
+}
+
+func (cryptoSource) Seed(int64) {}
+
+// laplaceNoise returns a sampler of Laplace noise of a scale, and its
+// variance.
+func laplaceNoise(r *rand.Rand, scale float64) (func() float64, float64) {
+	return func() float64 {
+		u := r.Float64() - 0.5
+		for u == -0.5 {
+			u = r.Float64() - 0.5
+		}
+		return -scale * math.Copysign(1, u) * math.Log(1-2*math.Abs(u))
+	}, 2 * scale * scale
+}
+
+// gaussianNoise returns a sampler of Gaussian noise of a standard deviation,
+// and its variance.
+func gaussianNoise(r *rand.Rand, sigma float64) (func() float64, float64) {
+	return func() float64 {
+		return r.NormFloat64() * sigma
+	}, sigma * sigma
+}
+
+func sortedIndexes(nodes map[h3.Index]*privateNode) []h3.Index {
+	xs := make([]h3.Index, 0, len(nodes))
+	for x := range nodes {
+		xs = append(xs, x)
+	}
+	sort.Slice(xs, func(i, j int) bool { return xs[i] < xs[j] })
+	return xs
+}
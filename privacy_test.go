@@ -0,0 +1,190 @@
+package placekey
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/diegosz/placekey-go/internal/h3"
+)
+
+func TestPrivacyAccountant(t *testing.T) {
+	if _, err := NewPrivacyAccountant(0, 0); !errors.Is(err, ErrInvalidEpsilon) {
+		t.Errorf("NewPrivacyAccountant() error = %v", err)
+	}
+	if _, err := NewPrivacyAccountant(1, 1); !errors.Is(err, ErrInvalidDelta) {
+		t.Errorf("NewPrivacyAccountant() error = %v", err)
+	}
+	a, err := NewPrivacyAccountant(1, 1e-5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		if err := a.Spend(1.0/3, 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := a.Spend(0.01, 0); !errors.Is(err, ErrBudgetExceeded) {
+		t.Errorf("Spend() error = %v", err)
+	}
+	if eps, delta := a.Remaining(); eps > 1e-9 || delta != 1e-5 {
+		t.Errorf("Remaining() got = %v, %v", eps, delta)
+	}
+	if eps, delta := a.Spent(); !almostEqual(eps, 1) || delta != 0 {
+		t.Errorf("Spent() got = %v, %v", eps, delta)
+	}
+}
+
+func TestPrivacyAccountant_Release(t *testing.T) {
+	c := NewH3()
+	defer c.Close()
+	agg := NewAggregator()
+	placeKeys := testPlaceKeys(t, c, 1000)
+	for i, pk := range placeKeys {
+		for j := 0; j < i%10; j++ {
+			if err := agg.Add(pk, 1); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	tests := []struct {
+		name string
+		opts PrivacyOptions
+	}{
+		{"laplace", PrivacyOptions{Mechanism: MechanismLaplace, Epsilon: 1, Resolutions: []int{9, 7, 5}, Domain: placeKeys}},
+		{"gaussian", PrivacyOptions{Mechanism: MechanismGaussian, Epsilon: 1, Delta: 1e-6, Resolutions: []int{8}, Domain: placeKeys}},
+		{"empty cell", PrivacyOptions{Mechanism: MechanismLaplace, Epsilon: 1, Domain: append(placeKeys[:len(placeKeys):len(placeKeys)], "@5vg-7gq-tvz")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, err := NewPrivacyAccountant(2, 1e-5)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.opts.Rand = rand.New(rand.NewSource(1))
+			got, err := a.Release(c, agg, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := len(tt.opts.Domain)
+			if len(got.PlaceKeys) != want || len(got.Rollups) != len(tt.opts.Resolutions) {
+				t.Fatalf("Release() got %d PlaceKeys and %d rollups", len(got.PlaceKeys), len(got.Rollups))
+			}
+			if _, ok := got.PlaceKeys["@5vg-7gq-tvz"]; ok != (want > len(placeKeys)) {
+				t.Errorf("Release() released @5vg-7gq-tvz: %v", ok)
+			}
+
+			// the noise is unbiased and of the expected magnitude
+			errSum, absSum := 0.0, 0.0
+			for pk, v := range got.PlaceKeys {
+				truth, _ := agg.Get(pk)
+				errSum += v - float64(truth.Count)
+				absSum += math.Abs(v - float64(truth.Count))
+			}
+			levels := float64(len(tt.opts.Resolutions) + 1)
+			if mean := errSum / float64(want); math.Abs(mean) > 0.5*levels {
+				t.Errorf("Release() mean error = %v", mean)
+			}
+			if mean := absSum / float64(want); mean < 0.1 || mean > 10*levels {
+				t.Errorf("Release() mean absolute error = %v", mean)
+			}
+
+			// the levels are consistent
+			fine := map[string]float64{}
+			for pk, v := range got.PlaceKeys {
+				h, _ := ToH3String(pk)
+				fine[h] = v
+			}
+			// resolutions are listed finest first
+			for _, res := range tt.opts.Resolutions {
+				sums := map[string]float64{}
+				for h, v := range fine {
+					x, err := strconv.ParseUint(h, 16, 64)
+					if err != nil {
+						t.Fatal(err)
+					}
+					sums[strconv.FormatUint(uint64(c.h3.ToParent(h3.Index(x), res)), 16)] += v
+				}
+				for h, v := range got.Rollups[res] {
+					if math.Abs(sums[h]-v) > 1e-6*math.Max(1, math.Abs(v)) {
+						t.Errorf("Release() rollup %d of %s got = %v, children sum to %v", res, h, v, sums[h])
+					}
+				}
+				fine = got.Rollups[res]
+			}
+
+			// the same seed gives the same release
+			tt.opts.Rand = rand.New(rand.NewSource(1))
+			again, err := a.Release(c, agg, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, again) {
+				t.Error("Release() is not reproducible")
+			}
+			if _, err := a.Release(c, agg, tt.opts); !errors.Is(err, ErrBudgetExceeded) {
+				t.Errorf("Release() error = %v", err)
+			}
+		})
+	}
+
+	a, err := NewPrivacyAccountant(10, 0.5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		opts PrivacyOptions
+		err  error
+	}{
+		{PrivacyOptions{Mechanism: 2, Epsilon: 1}, ErrInvalidMechanism},
+		{PrivacyOptions{Epsilon: 1, Delta: 0.1}, ErrInvalidDelta},
+		{PrivacyOptions{Mechanism: MechanismGaussian, Epsilon: 1}, ErrInvalidDelta},
+		{PrivacyOptions{Mechanism: MechanismGaussian, Epsilon: 2, Delta: 0.1}, ErrInvalidEpsilon},
+		{PrivacyOptions{Epsilon: 0}, ErrInvalidEpsilon},
+		{PrivacyOptions{Epsilon: 1, Sensitivity: -1}, ErrInvalidSensitivity},
+		{PrivacyOptions{Epsilon: 1, Resolutions: []int{10}}, ErrInvalidResolution},
+		{PrivacyOptions{Epsilon: 1}, ErrMissingDomain},
+	} {
+		if tt.err != ErrMissingDomain {
+			tt.opts.Domain = placeKeys
+		}
+		if _, err := a.Release(c, agg, tt.opts); !errors.Is(err, tt.err) {
+			t.Errorf("Release(%+v) error = %v, want %v", tt.opts, err, tt.err)
+		}
+	}
+	if eps, _ := a.Spent(); eps != 0 {
+		t.Errorf("Release() spent %v on invalid options", eps)
+	}
+}
+
+func TestPrivacyAccountant_Release_CryptoNoise(t *testing.T) {
+	c := NewH3()
+	defer c.Close()
+	agg := NewAggregator()
+	a, err := NewPrivacyAccountant(2, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := PrivacyOptions{Mechanism: MechanismLaplace, Epsilon: 1, Domain: []string{"@5vg-7gq-tvz"}}
+	first, err := a.Release(c, agg, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := a.Release(c, agg, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.PlaceKeys["@5vg-7gq-tvz"] == second.PlaceKeys["@5vg-7gq-tvz"] {
+		t.Error("Release() drew the same noise twice")
+	}
+
+	r := rand.New(cryptoSource{})
+	for i := 0; i < 1000; i++ {
+		if f := r.Float64(); f < 0 || f >= 1 {
+			t.Fatalf("Float64() got = %v", f)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package placekey
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+var ErrMissingLocation = errors.New("missing location")
+
+// Record is a row of a dataset located either by a (latitude, longitude) or by
+// a PlaceKey.
+type Record struct {
+	PlaceKey string
+	Lat, Lng float64
+	HasGeo   bool
+	Fields   map[string]string
+}
+
+// RecordFields names the fields holding the location of a record. A record is
+// located by its latitude and longitude fields when present, and by its
+// PlaceKey field otherwise.
+type RecordFields struct {
+	Lat, Lng, PlaceKey string
+}
+
+// DefaultRecordFields are the field names used when none are given.
+var DefaultRecordFields = RecordFields{Lat: "lat", Lng: "lng", PlaceKey: "placekey"}
+
+// RecordReader reads records from a dataset. Read returns io.EOF when there
+// are no more records.
+type RecordReader interface {
+	Read() (Record, error)
+}
+
+// Locate completes a record with the PlaceKey of its coordinates, when it has
+// coordinates but no PlaceKey.
+func (c *H3) Locate(r *Record) error {
+	if r.PlaceKey != "" {
+		if !FormatIsValid(r.PlaceKey) {
+			return ErrInvalidFormat
+		}
+		return nil
+	}
+	if !r.HasGeo {
+		return ErrMissingLocation
+	}
+	pk, err := c.FromGeo(r.Lat, r.Lng)
+	if err != nil {
+		return err
+	}
+	r.PlaceKey = pk
+	return nil
+}
+
+type csvRecordReader struct {
+	r      *csv.Reader
+	fields RecordFields
+	header []string
+	line   int
+}
+
+// NewCSVRecordReader returns a RecordReader reading a CSV dataset whose first
+// line is a header.
+func NewCSVRecordReader(r io.Reader, fields RecordFields) RecordReader {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	return &csvRecordReader{r: cr, fields: fields}
+}
+
+func (r *csvRecordReader) Read() (Record, error) {
+	if r.header == nil {
+		header, err := r.r.Read()
+		if err != nil {
+			return Record{}, err
+		}
+		r.header = header
+		r.line++
+	}
+	row, err := r.r.Read()
+	if err != nil {
+		return Record{}, err
+	}
+	r.line++
+	values := make(map[string]string, len(row))
+	for i, v := range row {
+		if i < len(r.header) {
+			values[r.header[i]] = v
+		}
+	}
+	rec, err := newRecord(values, r.fields)
+	if err != nil {
+		return Record{}, fmt.Errorf("line %d: %w", r.line, err)
+	}
+	return rec, nil
+}
+
+type ndjsonRecordReader struct {
+	s      *bufio.Scanner
+	fields RecordFields
+	line   int
+}
+
+// NewNDJSONRecordReader returns a RecordReader reading a dataset of JSON
+// objects, one per line. Values other than strings are kept as their JSON
+// text.
+func NewNDJSONRecordReader(r io.Reader, fields RecordFields) RecordReader {
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	return &ndjsonRecordReader{s: s, fields: fields}
+}
+
+func (r *ndjsonRecordReader) Read() (Record, error) {
+	for r.s.Scan() {
+		r.line++
+		line := strings.TrimSpace(r.s.Text())
+		if line == "" {
+			continue
+		}
+		raw := map[string]json.RawMessage{}
+		if err := json.Unmarshal([]byte(line), &raw); err != nil {
+			return Record{}, fmt.Errorf("line %d: %w", r.line, err)
+		}
+		values := make(map[string]string, len(raw))
+		for k, v := range raw {
+			var s string
+			if err := json.Unmarshal(v, &s); err == nil {
+				values[k] = s
+			} else {
+				values[k] = string(v)
+			}
+		}
+		rec, err := newRecord(values, r.fields)
+		if err != nil {
+			return Record{}, fmt.Errorf("line %d: %w", r.line, err)
+		}
+		return rec, nil
+	}
+	if err := r.s.Err(); err != nil {
+		return Record{}, err
+	}
+	return Record{}, io.EOF
+}
+
+func newRecord(values map[string]string, fields RecordFields) (Record, error) {
+	rec := Record{PlaceKey: values[fields.PlaceKey], Fields: values}
+	latStr, lngStr := values[fields.Lat], values[fields.Lng]
+	if latStr == "" || lngStr == "" {
+		return rec, nil
+	}
+	var err error
+	if rec.Lat, err = strconv.ParseFloat(latStr, 64); err != nil {
+		return rec, err
+	}
+	if rec.Lng, err = strconv.ParseFloat(lngStr, 64); err != nil {
+		return rec, err
+	}
+	rec.HasGeo = true
+	return rec, nil
+}
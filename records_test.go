@@ -0,0 +1,77 @@
+package placekey
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestCSVRecordReader(t *testing.T) {
+	in := "name,lat,lng,placekey\n" +
+		"city hall,37.779274,-122.419262,\n" +
+		"ferry building,,,zzw-22y@5vg-7gt-qzz\n" +
+		"bad,abc,1,\n"
+	r := NewCSVRecordReader(strings.NewReader(in), DefaultRecordFields)
+	got, err := r.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.HasGeo || !almostEqual(got.Lat, 37.779274) || got.Fields["name"] != "city hall" {
+		t.Errorf("Read() got = %+v", got)
+	}
+	got, err = r.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.HasGeo || got.PlaceKey != "zzw-22y@5vg-7gt-qzz" {
+		t.Errorf("Read() got = %+v", got)
+	}
+	if _, err = r.Read(); err == nil || !strings.HasPrefix(err.Error(), "line 4:") {
+		t.Errorf("Read() error = %v", err)
+	}
+	if _, err = r.Read(); !errors.Is(err, io.EOF) {
+		t.Errorf("Read() error = %v, wantErr %v", err, io.EOF)
+	}
+}
+
+func TestNDJSONRecordReader(t *testing.T) {
+	in := `{"id":1,"y":"37.779274","x":-122.419262}` + "\n\n" +
+		`{"id":2,"pk":"@5vg-7gt-qzz"}` + "\n"
+	r := NewNDJSONRecordReader(strings.NewReader(in), RecordFields{Lat: "y", Lng: "x", PlaceKey: "pk"})
+	got, err := r.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.HasGeo || !almostEqual(got.Lng, -122.419262) || got.Fields["id"] != "1" {
+		t.Errorf("Read() got = %+v", got)
+	}
+	got, err = r.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.HasGeo || got.PlaceKey != "@5vg-7gt-qzz" {
+		t.Errorf("Read() got = %+v", got)
+	}
+	if _, err = r.Read(); !errors.Is(err, io.EOF) {
+		t.Errorf("Read() error = %v, wantErr %v", err, io.EOF)
+	}
+}
+
+func TestH3_Locate(t *testing.T) {
+	c := NewH3()
+	defer c.Close()
+	r := Record{Lat: 37.779274, Lng: -122.419262, HasGeo: true}
+	if err := c.Locate(&r); err != nil {
+		t.Fatal(err)
+	}
+	if r.PlaceKey != "@5vg-7gq-tvz" {
+		t.Errorf("Locate() got = %v", r.PlaceKey)
+	}
+	if err := c.Locate(&Record{}); !errors.Is(err, ErrMissingLocation) {
+		t.Errorf("Locate() error = %v, wantErr %v", err, ErrMissingLocation)
+	}
+	if err := c.Locate(&Record{PlaceKey: "@abc"}); !errors.Is(err, ErrInvalidFormat) {
+		t.Errorf("Locate() error = %v, wantErr %v", err, ErrInvalidFormat)
+	}
+}
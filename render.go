@@ -0,0 +1,276 @@
+package placekey
+
+import (
+	"bufio"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"image/color"
+	"io"
+	"math"
+	"sort"
+)
+
+var ErrInvalidSize = errors.New("invalid size")
+
+// maxMercatorLat is the latitude at which Web Mercator is cut, making the
+// projected world square.
+const maxMercatorLat = 85.05112878
+
+// mercator projects a (latitude, longitude) with Web Mercator onto the unit
+// square, x growing eastwards from -180 degrees and y southwards from
+// maxMercatorLat. Longitudes outside -180..180 degrees map outside the square.
+func mercator(lat, lng float64) (x, y float64) {
+	lat = math.Max(-maxMercatorLat, math.Min(maxMercatorLat, lat))
+	s := math.Sin(radians(lat))
+	return (lng + 180) / 360, 0.5 - math.Log((1+s)/(1-s))/(4*math.Pi)
+}
+
+// ColorScale maps values linearly from the From colour at Min to the To colour
+// at Max, clamping the values outside.
+type ColorScale struct {
+	Min, Max float64
+	From, To color.Color
+}
+
+// DefaultColorScale returns a yellow to red ColorScale over a range of values.
+func DefaultColorScale(min, max float64) ColorScale {
+	return ColorScale{
+		Min:  min,
+		Max:  max,
+		From: color.NRGBA{R: 0xff, G: 0xed, B: 0xa0, A: 0xff},
+		To:   color.NRGBA{R: 0xbd, G: 0x00, B: 0x26, A: 0xff},
+	}
+}
+
+// Color returns the colour of a value.
+func (s ColorScale) Color(v float64) color.Color {
+	t := 0.0
+	if s.Max > s.Min {
+		t = math.Max(0, math.Min(1, (v-s.Min)/(s.Max-s.Min)))
+	}
+	from := color.NRGBAModel.Convert(s.From).(color.NRGBA)
+	to := color.NRGBAModel.Convert(s.To).(color.NRGBA)
+	lerp := func(a, b uint8) uint8 {
+		return uint8(math.Round(float64(a) + t*(float64(b)-float64(a))))
+	}
+	return color.NRGBA{
+		R: lerp(from.R, to.R),
+		G: lerp(from.G, to.G),
+		B: lerp(from.B, to.B),
+		A: lerp(from.A, to.A),
+	}
+}
+
+// valueRange returns the smallest and largest values of a map.
+func valueRange(values map[string]float64) (min, max float64) {
+	first := true
+	for _, v := range values {
+		if first || v < min {
+			min = v
+		}
+		if first || v > max {
+			max = v
+		}
+		first = false
+	}
+	return min, max
+}
+
+// CategoryPalette is the default palette of RenderOptions.Categories.
+var CategoryPalette = []color.Color{
+	color.NRGBA{R: 0x4e, G: 0x79, B: 0xa7, A: 0xff},
+	color.NRGBA{R: 0xf2, G: 0x8e, B: 0x2b, A: 0xff},
+	color.NRGBA{R: 0xe1, G: 0x57, B: 0x59, A: 0xff},
+	color.NRGBA{R: 0x76, G: 0xb7, B: 0xb2, A: 0xff},
+	color.NRGBA{R: 0x59, G: 0xa1, B: 0x4f, A: 0xff},
+	color.NRGBA{R: 0xed, G: 0xc9, B: 0x48, A: 0xff},
+	color.NRGBA{R: 0xb0, G: 0x7a, B: 0xa1, A: 0xff},
+	color.NRGBA{R: 0xff, G: 0x9d, B: 0xa7, A: 0xff},
+	color.NRGBA{R: 0x9c, G: 0x75, B: 0x5f, A: 0xff},
+	color.NRGBA{R: 0xba, G: 0xb0, B: 0xac, A: 0xff},
+}
+
+// RenderOptions configures RenderSVG.
+type RenderOptions struct {
+	// Width is the width of the image in pixels, 800 when zero. The height
+	// follows the aspect ratio of the PlaceKeys.
+	Width int
+	// Padding is the margin around the PlaceKeys in pixels.
+	Padding float64
+	// Values fills the PlaceKeys by value with Scale, the DefaultColorScale
+	// of the range of the values when nil.
+	Values map[string]float64
+	Scale  *ColorScale
+	// Categories fills the PlaceKeys by category with Palette, the
+	// CategoryPalette when empty, the sorted categories taking the colours in
+	// turn. Categories take precedence over Values.
+	Categories map[string]string
+	Palette    []color.Color
+	// Fill is the colour of the other PlaceKeys, light grey when nil, and
+	// Stroke the colour of the outlines, dark grey when nil.
+	Fill, Stroke color.Color
+	// Labels writes the PlaceKey at the center of each cell.
+	Labels bool
+}
+
+// renderCell is a PlaceKey projected to pixels.
+type renderCell struct {
+	placeKey string
+	ring     []point
+	center   point
+	fill     color.Color
+}
+
+// RenderSVG draws PlaceKeys as SVG polygons, projected with Web Mercator. The
+// keys of Values and Categories are looked up as given, so they must be
+// spelled like the PlaceKeys.
+func (c *H3) RenderSVG(w io.Writer, placeKeys []string, opts RenderOptions) error {
+	if opts.Width < 0 || opts.Padding < 0 || math.IsNaN(opts.Padding) || math.IsInf(opts.Padding, 0) {
+		return ErrInvalidSize
+	}
+	if opts.Width == 0 {
+		opts.Width = 800
+	}
+	if 2*opts.Padding >= float64(opts.Width) {
+		return ErrInvalidSize
+	}
+	if opts.Fill == nil {
+		opts.Fill = color.NRGBA{R: 0xcc, G: 0xcc, B: 0xcc, A: 0xff}
+	}
+	if opts.Stroke == nil {
+		opts.Stroke = color.NRGBA{R: 0x33, G: 0x33, B: 0x33, A: 0xff}
+	}
+	fill := func(pk string) color.Color { return opts.Fill }
+	switch {
+	case len(opts.Categories) > 0:
+		palette := opts.Palette
+		if len(palette) == 0 {
+			palette = CategoryPalette
+		}
+		categories := []string{}
+		seen := map[string]bool{}
+		for _, category := range opts.Categories {
+			if !seen[category] {
+				seen[category] = true
+				categories = append(categories, category)
+			}
+		}
+		sort.Strings(categories)
+		colors := make(map[string]color.Color, len(categories))
+		for i, category := range categories {
+			colors[category] = palette[i%len(palette)]
+		}
+		fill = func(pk string) color.Color {
+			if category, ok := opts.Categories[pk]; ok {
+				return colors[category]
+			}
+			return opts.Fill
+		}
+	case len(opts.Values) > 0:
+		scale := opts.Scale
+		if scale == nil {
+			s := DefaultColorScale(valueRange(opts.Values))
+			scale = &s
+		}
+		fill = func(pk string) color.Color {
+			if v, ok := opts.Values[pk]; ok {
+				return scale.Color(v)
+			}
+			return opts.Fill
+		}
+	}
+
+	cells := make([]renderCell, 0, len(placeKeys))
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	lng0 := 0.0
+	for i, pk := range placeKeys {
+		if !FormatIsValid(pk) {
+			return ErrInvalidFormat
+		}
+		lat, lng, err := c.ToGeo(pk)
+		if err != nil {
+			return err
+		}
+		boundary, err := c.ToGeoBoundary(pk)
+		if err != nil {
+			return err
+		}
+		if i == 0 {
+			lng0 = lng
+		}
+		// cells are unwrapped around the first one, so that sets crossing the
+		// antimeridian stay in one piece
+		lng = lng0 + normalizeLng(lng-lng0)
+		cell := renderCell{placeKey: pk, fill: fill(pk)}
+		cell.center.x, cell.center.y = mercator(lat, lng)
+		for _, p := range unwrapRing(boundary, lng) {
+			var q point
+			q.x, q.y = mercator(p.y, p.x)
+			cell.ring = append(cell.ring, q)
+			minX, minY = math.Min(minX, q.x), math.Min(minY, q.y)
+			maxX, maxY = math.Max(maxX, q.x), math.Max(maxY, q.y)
+		}
+		cells = append(cells, cell)
+	}
+
+	width := float64(opts.Width)
+	height := 2 * opts.Padding
+	scale := 0.0
+	if len(cells) > 0 {
+		scale = (width - 2*opts.Padding) / math.Max(maxX-minX, maxY-minY)
+		height += (maxY - minY) * scale
+	}
+	project := func(p point) point {
+		return point{x: opts.Padding + (p.x-minX)*scale, y: opts.Padding + (p.y-minY)*scale}
+	}
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%s" viewBox="0 0 %d %s">`+"\n",
+		opts.Width, formatPixel(height), opts.Width, formatPixel(height))
+	stroke, strokeOpacity := svgColor(opts.Stroke)
+	fmt.Fprintf(bw, `<g stroke="%s" stroke-opacity="%s" stroke-width="1">`+"\n", stroke, strokeOpacity)
+	for _, cell := range cells {
+		fill, fillOpacity := svgColor(cell.fill)
+		fmt.Fprint(bw, `<polygon points="`)
+		for i, p := range cell.ring {
+			if i > 0 {
+				fmt.Fprint(bw, " ")
+			}
+			p = project(p)
+			fmt.Fprintf(bw, "%s,%s", formatPixel(p.x), formatPixel(p.y))
+		}
+		fmt.Fprintf(bw, `" fill="%s" fill-opacity="%s"><title>`, fill, fillOpacity)
+		if err := xml.EscapeText(bw, []byte(cell.placeKey)); err != nil {
+			return err
+		}
+		fmt.Fprint(bw, "</title></polygon>\n")
+	}
+	fmt.Fprint(bw, "</g>\n")
+	if opts.Labels {
+		fmt.Fprint(bw, `<g font-family="sans-serif" font-size="10" text-anchor="middle" dominant-baseline="middle">`+"\n")
+		for _, cell := range cells {
+			p := project(cell.center)
+			fmt.Fprintf(bw, `<text x="%s" y="%s">`, formatPixel(p.x), formatPixel(p.y))
+			if err := xml.EscapeText(bw, []byte(cell.placeKey)); err != nil {
+				return err
+			}
+			fmt.Fprint(bw, "</text>\n")
+		}
+		fmt.Fprint(bw, "</g>\n")
+	}
+	fmt.Fprint(bw, "</svg>\n")
+	return bw.Flush()
+}
+
+// svgColor returns a colour as an SVG hexadecimal colour and an opacity.
+func svgColor(c color.Color) (string, string) {
+	n := color.NRGBAModel.Convert(c).(color.NRGBA)
+	return fmt.Sprintf("#%02x%02x%02x", n.R, n.G, n.B), formatFloat(math.Round(float64(n.A)/255*1000) / 1000)
+}
+
+// formatPixel formats a pixel coordinate with two decimals at most.
+func formatPixel(f float64) string {
+	return formatFloat(math.Round(f*100) / 100)
+}
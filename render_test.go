@@ -0,0 +1,167 @@
+package placekey
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"image/color"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestMercator(t *testing.T) {
+	tests := []struct {
+		lat, lng float64
+		x, y     float64
+	}{
+		{0, 0, 0.5, 0.5},
+		{0, -180, 0, 0.5},
+		{0, 180, 1, 0.5},
+		{maxMercatorLat, 0, 0.5, 0},
+		{-maxMercatorLat, 0, 0.5, 1},
+		{90, 0, 0.5, 0},
+	}
+	for _, tt := range tests {
+		x, y := mercator(tt.lat, tt.lng)
+		if math.Abs(x-tt.x) > 1e-9 || math.Abs(y-tt.y) > 1e-9 {
+			t.Errorf("mercator(%v, %v) got = (%v, %v), want (%v, %v)", tt.lat, tt.lng, x, y, tt.x, tt.y)
+		}
+	}
+}
+
+func TestColorScale(t *testing.T) {
+	s := ColorScale{Min: 0, Max: 10, From: color.Black, To: color.White}
+	tests := []struct {
+		v    float64
+		want color.NRGBA
+	}{
+		{-5, color.NRGBA{A: 0xff}},
+		{0, color.NRGBA{A: 0xff}},
+		{5, color.NRGBA{R: 0x80, G: 0x80, B: 0x80, A: 0xff}},
+		{10, color.NRGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}},
+		{20, color.NRGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}},
+	}
+	for _, tt := range tests {
+		if got := s.Color(tt.v); got != tt.want {
+			t.Errorf("Color(%v) got = %v, want %v", tt.v, got, tt.want)
+		}
+	}
+	if got := (ColorScale{Min: 1, Max: 1, From: color.Black, To: color.White}).Color(1); got != (color.NRGBA{A: 0xff}) {
+		t.Errorf("Color() of an empty range got = %v", got)
+	}
+}
+
+func TestRenderSVG(t *testing.T) {
+	c := NewH3()
+	defer c.Close()
+	placeKeys := []string{"@5vg-7gq-tvz", "@5vg-7gt-qzz", "@5vg-82n-kzz"}
+	tests := []struct {
+		name     string
+		opts     RenderOptions
+		contains []string
+	}{
+		{
+			"plain",
+			RenderOptions{},
+			[]string{`width="800"`, `fill="#cccccc"`, `stroke="#333333"`, "<title>@5vg-7gq-tvz</title>"},
+		},
+		{
+			"values",
+			RenderOptions{Width: 400, Padding: 10, Values: map[string]float64{"@5vg-7gq-tvz": 1, "@5vg-7gt-qzz": 3}},
+			[]string{`width="400"`, `fill="#ffeda0"`, `fill="#bd0026"`, `fill="#cccccc"`},
+		},
+		{
+			"categories",
+			RenderOptions{
+				Categories: map[string]string{"@5vg-7gq-tvz": "b", "@5vg-7gt-qzz": "a", "@5vg-82n-kzz": "a"},
+				Palette:    []color.Color{color.NRGBA{R: 0xff, A: 0xff}, color.NRGBA{B: 0xff, A: 0x80}},
+				Labels:     true,
+			},
+			[]string{`fill="#ff0000" fill-opacity="1"`, `fill="#0000ff" fill-opacity="0.502"`, ">@5vg-82n-kzz</text>"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := c.RenderSVG(&buf, placeKeys, tt.opts); err != nil {
+				t.Fatal(err)
+			}
+			svg := buf.String()
+			for _, s := range tt.contains {
+				if !strings.Contains(svg, s) {
+					t.Errorf("RenderSVG() got no %s in %s", s, svg)
+				}
+			}
+			if got := strings.Count(svg, "<polygon "); got != len(placeKeys) {
+				t.Errorf("RenderSVG() got %d polygons", got)
+			}
+			if got := strings.Count(svg, "<text "); (got > 0) != tt.opts.Labels {
+				t.Errorf("RenderSVG() got %d labels", got)
+			}
+			var doc struct {
+				Width    float64 `xml:"width,attr"`
+				Height   float64 `xml:"height,attr"`
+				Polygons []struct {
+					Points string `xml:"points,attr"`
+				} `xml:"g>polygon"`
+			}
+			if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+				t.Fatal(err)
+			}
+			for _, p := range doc.Polygons {
+				for _, xy := range strings.Fields(p.Points) {
+					var x, y float64
+					if _, err := fmt.Sscanf(xy, "%g,%g", &x, &y); err != nil {
+						t.Fatal(err)
+					}
+					if x < 0 || x > doc.Width || y < 0 || y > doc.Height {
+						t.Errorf("RenderSVG() point %s outside of %vx%v", xy, doc.Width, doc.Height)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestRenderSVG_Antimeridian(t *testing.T) {
+	c := NewH3()
+	defer c.Close()
+	west, err := c.FromGeo(0, 179.999)
+	if err != nil {
+		t.Fatal(err)
+	}
+	east, err := c.FromGeo(0, -179.999)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := c.RenderSVG(&buf, []string{west, east}, RenderOptions{Width: 100}); err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		Height float64 `xml:"height,attr"`
+	}
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	// two neighboring cells are about as wide as they are tall
+	if doc.Height < 20 {
+		t.Errorf("RenderSVG() across the antimeridian got height %v", doc.Height)
+	}
+}
+
+func TestRenderSVG_Errors(t *testing.T) {
+	c := NewH3()
+	defer c.Close()
+	var buf bytes.Buffer
+	for _, opts := range []RenderOptions{{Width: -1}, {Width: 10, Padding: 5}, {Padding: math.NaN()}} {
+		if err := c.RenderSVG(&buf, nil, opts); !errors.Is(err, ErrInvalidSize) {
+			t.Errorf("RenderSVG(%+v) error = %v", opts, err)
+		}
+	}
+	if err := c.RenderSVG(&buf, []string{"invalid"}, RenderOptions{}); !errors.Is(err, ErrInvalidFormat) {
+		t.Errorf("RenderSVG() error = %v", err)
+	}
+}
@@ -0,0 +1,213 @@
+package placekey
+
+import (
+	"container/heap"
+	"errors"
+	"math"
+
+	"github.com/diegosz/placekey-go/internal/h3"
+)
+
+var ErrNoPath = errors.New("no path")
+var ErrInvalidCost = errors.New("invalid cost")
+var ErrInvalidBudget = errors.New("invalid budget")
+var ErrSearchLimit = errors.New("search limit reached")
+
+// CostFunc returns the cost of entering a PlaceKey, +Inf when it is
+// impassable.
+type CostFunc func(placeKey string) float64
+
+// CostMap returns a CostFunc looking costs up in a map, with a default cost
+// for the PlaceKeys not in it.
+func CostMap(costs map[string]float64, def float64) CostFunc {
+	cells := make(map[string]float64, len(costs))
+	for pk, cost := range costs {
+		if x, err := ToH3Index(pk); err == nil {
+			cells[encodeH3Int(uint64(x))] = cost
+		}
+	}
+	return func(placeKey string) float64 {
+		if cost, ok := cells[placeKey]; ok {
+			return cost
+		}
+		return def
+	}
+}
+
+// RouteOptions configures ShortestPath and Isochrone.
+type RouteOptions struct {
+	// Cost is the cost of entering each cell, 1 when nil. The cost of a path
+	// is the sum of the costs of its cells but the first one.
+	Cost CostFunc
+	// MinCost is a lower bound of Cost, used to guide ShortestPath towards the
+	// destination with A*, 1 when Cost is nil. It must not exceed the cost of
+	// any cell, and ShortestPath falls back to Dijkstra when it is zero.
+	MinCost float64
+	// MaxCells is the largest number of cells explored before giving up with
+	// ErrSearchLimit, 1<<22 when zero.
+	MaxCells int
+}
+
+// ShortestPath returns the cheapest path of PlaceKeys between two PlaceKeys,
+// both included, and its cost, moving from a cell to its neighbors.
+func (c *H3) ShortestPath(from, to string, opts RouteOptions) ([]string, float64, error) {
+	start, err := ToH3Index(from)
+	if err != nil {
+		return nil, 0, err
+	}
+	end, err := ToH3Index(to)
+	if err != nil {
+		return nil, 0, err
+	}
+	if opts.Cost == nil && opts.MinCost == 0 {
+		opts.MinCost = 1
+	}
+	if opts.MinCost < 0 || math.IsNaN(opts.MinCost) || math.IsInf(opts.MinCost, 0) {
+		return nil, 0, ErrInvalidCost
+	}
+	heuristic := func(x h3.Index) float64 {
+		if opts.MinCost == 0 {
+			return 0
+		}
+		if d := c.h3.Distance(x, end); d > 0 {
+			return opts.MinCost * float64(d)
+		}
+		return 0
+	}
+	var path []string
+	var cost float64
+	found := false
+	err = c.route([]h3.Index{start}, opts, heuristic, func(x h3.Index, g float64, prev map[h3.Index]h3.Index) bool {
+		if x != end {
+			return true
+		}
+		found, cost = true, g
+		for ; x != start; x = prev[x] {
+			path = append(path, encodeH3Int(uint64(x)))
+		}
+		path = append(path, encodeH3Int(uint64(start)))
+		for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+			path[i], path[j] = path[j], path[i]
+		}
+		return false
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	if !found {
+		return nil, 0, ErrNoPath
+	}
+	return path, cost, nil
+}
+
+// Isochrone returns the PlaceKeys reachable from any of the origins within a
+// cost budget, with the cost of reaching them.
+func (c *H3) Isochrone(origins []string, budget float64, opts RouteOptions) (map[string]float64, error) {
+	if budget < 0 || math.IsNaN(budget) || math.IsInf(budget, 0) {
+		return nil, ErrInvalidBudget
+	}
+	starts := make([]h3.Index, 0, len(origins))
+	for _, pk := range origins {
+		x, err := ToH3Index(pk)
+		if err != nil {
+			return nil, err
+		}
+		starts = append(starts, x)
+	}
+	out := map[string]float64{}
+	err := c.route(starts, opts, func(h3.Index) float64 { return 0 }, func(x h3.Index, g float64, _ map[h3.Index]h3.Index) bool {
+		if g > budget {
+			return false
+		}
+		out[encodeH3Int(uint64(x))] = g
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// route runs A* from the starts, calling settle with each cell in order of
+// increasing estimated cost, its cost and the predecessors of the cells
+// reached so far, until settle returns false or no cell is left. A zero
+// heuristic makes it Dijkstra's algorithm. The heuristic falls back to zero
+// where the grid distance is not defined, so cells are reopened when a
+// cheaper way to them is found, which keeps A* optimal.
+func (c *H3) route(starts []h3.Index, opts RouteOptions, heuristic func(h3.Index) float64, settle func(x h3.Index, g float64, prev map[h3.Index]h3.Index) bool) error {
+	maxCells := opts.MaxCells
+	if maxCells <= 0 {
+		maxCells = int(maxCoverCells)
+	}
+	cost := func(x h3.Index) (float64, error) {
+		if opts.Cost == nil {
+			return 1, nil
+		}
+		v := opts.Cost(encodeH3Int(uint64(x)))
+		if v < 0 || math.IsNaN(v) {
+			return 0, ErrInvalidCost
+		}
+		return v, nil
+	}
+	g := map[h3.Index]float64{}
+	prev := map[h3.Index]h3.Index{}
+	queue := &routeQueue{}
+	for _, x := range starts {
+		if _, ok := g[x]; !ok {
+			g[x] = 0
+			heap.Push(queue, routeItem{x: x, f: heuristic(x)})
+		}
+	}
+	explored := 0
+	for queue.Len() > 0 {
+		item := heap.Pop(queue).(routeItem)
+		if item.g > g[item.x] {
+			// a cheaper way to the cell was found since
+			continue
+		}
+		if !settle(item.x, item.g, prev) {
+			return nil
+		}
+		if explored++; explored > maxCells {
+			return ErrSearchLimit
+		}
+		for _, n := range c.h3.KRing(item.x, 1) {
+			if n == item.x {
+				continue
+			}
+			step, err := cost(n)
+			if err != nil {
+				return err
+			}
+			if math.IsInf(step, 1) {
+				continue
+			}
+			if old, ok := g[n]; ok && old <= item.g+step {
+				continue
+			}
+			g[n] = item.g + step
+			prev[n] = item.x
+			heap.Push(queue, routeItem{x: n, g: g[n], f: g[n] + heuristic(n)})
+		}
+	}
+	return nil
+}
+
+type routeItem struct {
+	x    h3.Index
+	g, f float64
+}
+
+// routeQueue is a min-heap of cells by estimated total cost.
+type routeQueue []routeItem
+
+func (q routeQueue) Len() int            { return len(q) }
+func (q routeQueue) Less(i, j int) bool  { return q[i].f < q[j].f }
+func (q routeQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *routeQueue) Push(x interface{}) { *q = append(*q, x.(routeItem)) }
+func (q *routeQueue) Pop() interface{} {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
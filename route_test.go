@@ -0,0 +1,110 @@
+package placekey
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestH3_ShortestPath(t *testing.T) {
+	c := NewH3()
+	defer c.Close()
+	path, cost, err := c.ShortestPath("@5vg-7gq-tvz", "@5vg-7gt-qzz", RouteOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cost != 24 || len(path) != 25 || path[0] != "@5vg-7gq-tvz" || path[24] != "@5vg-7gt-qzz" {
+		t.Fatalf("ShortestPath() got cost %v, path %v", cost, path)
+	}
+	for i := 1; i < len(path); i++ {
+		if d, err := c.GridDistance(path[i-1], path[i]); err != nil || d != 1 {
+			t.Errorf("ShortestPath() steps %d cells from %s to %s", d, path[i-1], path[i])
+		}
+	}
+
+	// A* agrees with Dijkstra on random costs
+	r := rand.New(rand.NewSource(1))
+	costs := map[string]float64{}
+	for _, pk := range testRing(t, c, "@5vg-7gq-tvz", 30) {
+		costs[pk] = 1 + 9*r.Float64()
+	}
+	cost1 := CostMap(costs, 1)
+	_, astar, err := c.ShortestPath("@5vg-7gq-tvz", "@5vg-7gt-qzz", RouteOptions{Cost: cost1, MinCost: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	path, dijkstra, err := c.ShortestPath("@5vg-7gq-tvz", "@5vg-7gt-qzz", RouteOptions{Cost: cost1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !almostEqual(astar, dijkstra) || dijkstra <= 24 {
+		t.Errorf("ShortestPath() A* got %v, Dijkstra %v", astar, dijkstra)
+	}
+	sum := 0.0
+	for _, pk := range path[1:] {
+		sum += cost1(pk)
+	}
+	if !almostEqual(sum, dijkstra) {
+		t.Errorf("ShortestPath() path costs %v, want %v", sum, dijkstra)
+	}
+
+	// a wall with a gap makes a detour, a closed wall blocks
+	x, err := ToH3Index("@5vg-7gq-tvz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wall := map[string]float64{}
+	ring := c.h3.HexRing(x, 3)
+	for _, y := range ring {
+		wall[encodeH3Int(uint64(y))] = math.Inf(1)
+	}
+	_, cost, err = c.ShortestPath("@5vg-7gq-tvz", "@5vg-7gt-qzz", RouteOptions{Cost: CostMap(wall, 1), MinCost: 1})
+	if !errors.Is(err, ErrNoPath) {
+		t.Errorf("ShortestPath() through a wall got cost %v, error %v", cost, err)
+	}
+	delete(wall, encodeH3Int(uint64(ring[0])))
+	path, cost, err = c.ShortestPath("@5vg-7gq-tvz", "@5vg-7gt-qzz", RouteOptions{Cost: CostMap(wall, 1), MinCost: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cost <= 24 || !containsString(path, encodeH3Int(uint64(ring[0]))) {
+		t.Errorf("ShortestPath() through a gap got cost %v, path %v", cost, path)
+	}
+
+	if _, _, err := c.ShortestPath("@5vg-7gq-tvz", "@5vg-7gt-qzz", RouteOptions{MaxCells: 10}); !errors.Is(err, ErrSearchLimit) {
+		t.Errorf("ShortestPath() error = %v", err)
+	}
+	negative := func(string) float64 { return -1 }
+	if _, _, err := c.ShortestPath("@5vg-7gq-tvz", "@5vg-7gt-qzz", RouteOptions{Cost: negative}); !errors.Is(err, ErrInvalidCost) {
+		t.Errorf("ShortestPath() error = %v", err)
+	}
+}
+
+func TestH3_Isochrone(t *testing.T) {
+	c := NewH3()
+	defer c.Close()
+	got, err := c.Isochrone([]string{"@5vg-7gq-tvz"}, 2, RouteOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 19 || got["@5vg-7gq-tvz"] != 0 {
+		t.Errorf("Isochrone() got = %v", got)
+	}
+	for pk, cost := range got {
+		if d, err := c.GridDistance("@5vg-7gq-tvz", pk); err != nil || float64(d) != cost {
+			t.Errorf("Isochrone() %s got cost %v, grid distance %d", pk, cost, d)
+		}
+	}
+
+	got, err = c.Isochrone([]string{"@5vg-7gq-tvz", "@5vg-7gt-qzz"}, 1.5, RouteOptions{Cost: CostMap(nil, 0.5)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2*37 {
+		t.Errorf("Isochrone() from two origins got %d cells", len(got))
+	}
+	if _, err := c.Isochrone([]string{"@5vg-7gq-tvz"}, -1, RouteOptions{}); !errors.Is(err, ErrInvalidBudget) {
+		t.Errorf("Isochrone() error = %v", err)
+	}
+}
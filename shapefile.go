@@ -0,0 +1,534 @@
+package placekey
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/diegosz/placekey-go/internal/h3"
+)
+
+// Shape types of the shapefiles.
+const (
+	shapeNull        = 0
+	shapePoint       = 1
+	shapePolygon     = 5
+	shapeMultiPoint  = 8
+	shapePointZ      = 11
+	shapePolygonZ    = 15
+	shapeMultiPointZ = 18
+	shapePointM      = 21
+	shapePolygonM    = 25
+	shapeMultiPointM = 28
+)
+
+// wgs84PRJ is the projection file of WGS 84 coordinates.
+const wgs84PRJ = `GEOGCS["GCS_WGS_1984",DATUM["D_WGS_1984",SPHEROID["WGS_1984",6378137.0,298.257223563]],PRIMEM["Greenwich",0.0],UNIT["Degree",0.0174532925199433]]`
+
+var ErrInvalidShapefile = errors.New("invalid shapefile")
+var ErrUnsupportedShape = errors.New("unsupported shape")
+var ErrInvalidFieldName = errors.New("invalid field name")
+var ErrTooManyFields = errors.New("too many fields")
+
+// ShapefileWriters are the files of a shapefile being written. PRJ may be nil.
+type ShapefileWriters struct {
+	SHP, SHX, DBF, PRJ io.Writer
+}
+
+// dbfField is a column of a dBASE table.
+type dbfField struct {
+	name     string
+	kind     byte
+	width    int
+	decimals int
+}
+
+// WriteShapefile writes records as the hexagons of their PlaceKeys in a polygon
+// shapefile, located with Locate. The first column of the table is the
+// PlaceKey, followed by the fields of the records in alphabetical order: a
+// column is numeric when all its values are decimal numbers, and text
+// otherwise. Field names are at most 10 bytes long, and text values are cut to
+// 254 bytes at a rune boundary.
+func (c *H3) WriteShapefile(w ShapefileWriters, records []Record) error {
+	pkField := DefaultRecordFields.PlaceKey
+	names := map[string]bool{}
+	placeKeys := make([]string, len(records))
+	for i := range records {
+		r := records[i]
+		if err := c.Locate(&r); err != nil {
+			return err
+		}
+		placeKeys[i] = r.PlaceKey
+		for k := range r.Fields {
+			if k != pkField {
+				names[k] = true
+			}
+		}
+	}
+	fields := []dbfField{{name: pkField, kind: 'C', width: 1}}
+	for _, pk := range placeKeys {
+		if len(pk) > fields[0].width {
+			fields[0].width = len(pk)
+		}
+	}
+	sorted := make([]string, 0, len(names))
+	for k := range names {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+	for _, k := range sorted {
+		if k == "" || len(k) > 10 || strings.IndexByte(k, 0) >= 0 {
+			return fmt.Errorf("%w: %q", ErrInvalidFieldName, k)
+		}
+		fields = append(fields, dbfColumn(k, records))
+	}
+
+	// the rings of the cells, clockwise, unwrapped around their centers
+	rings := make([][]point, len(placeKeys))
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for i, pk := range placeKeys {
+		x, err := ToH3Index(pk)
+		if err != nil {
+			return err
+		}
+		center := c.h3.ToGeo(x)
+		ring := loopPoints(c.h3.ToGeoBoundary(x), center.Longitude)
+		for j, k := 0, len(ring)-1; j < k; j, k = j+1, k-1 {
+			ring[j], ring[k] = ring[k], ring[j]
+		}
+		ring = append(ring, ring[0])
+		for _, p := range ring {
+			minX, minY = math.Min(minX, p.x), math.Min(minY, p.y)
+			maxX, maxY = math.Max(maxX, p.x), math.Max(maxY, p.y)
+		}
+		rings[i] = ring
+	}
+	if len(rings) == 0 {
+		minX, minY, maxX, maxY = 0, 0, 0, 0
+	}
+
+	var shp, shx bytes.Buffer
+	offset := 50 // in 16-bit words, after the header
+	for i, ring := range rings {
+		length := (44 + 4 + 16*len(ring)) / 2
+		writeBigEndian(&shx, int32(offset), int32(length))
+		writeBigEndian(&shp, int32(i+1), int32(length))
+		rMinX, rMinY := math.Inf(1), math.Inf(1)
+		rMaxX, rMaxY := math.Inf(-1), math.Inf(-1)
+		for _, p := range ring {
+			rMinX, rMinY = math.Min(rMinX, p.x), math.Min(rMinY, p.y)
+			rMaxX, rMaxY = math.Max(rMaxX, p.x), math.Max(rMaxY, p.y)
+		}
+		writeLittleEndian(&shp, int32(shapePolygon), rMinX, rMinY, rMaxX, rMaxY, int32(1), int32(len(ring)), int32(0))
+		for _, p := range ring {
+			writeLittleEndian(&shp, p.x, p.y)
+		}
+		offset += 4 + length
+	}
+	header := func(words int) []byte {
+		var b bytes.Buffer
+		writeBigEndian(&b, int32(9994), [5]int32{}, int32(words))
+		writeLittleEndian(&b, int32(1000), int32(shapePolygon), minX, minY, maxX, maxY, [4]float64{})
+		return b.Bytes()
+	}
+	// the table is built first so that nothing is written when it is too large
+	var dbf bytes.Buffer
+	if err := writeDBF(&dbf, fields, records, placeKeys); err != nil {
+		return err
+	}
+	if _, err := w.SHP.Write(append(header(50+shp.Len()/2), shp.Bytes()...)); err != nil {
+		return err
+	}
+	if _, err := w.SHX.Write(append(header(50+shx.Len()/2), shx.Bytes()...)); err != nil {
+		return err
+	}
+	if _, err := w.DBF.Write(dbf.Bytes()); err != nil {
+		return err
+	}
+	if w.PRJ != nil {
+		if _, err := io.WriteString(w.PRJ, wgs84PRJ); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CreateShapefile writes records like WriteShapefile in the .shp, .shx, .dbf
+// and .prj files of a name, with or without its .shp extension.
+func (c *H3) CreateShapefile(name string, records []Record) (err error) {
+	base := strings.TrimSuffix(name, filepath.Ext(name))
+	var w ShapefileWriters
+	for _, f := range []struct {
+		ext string
+		w   *io.Writer
+	}{{".shp", &w.SHP}, {".shx", &w.SHX}, {".dbf", &w.DBF}, {".prj", &w.PRJ}} {
+		file, err := os.Create(base + f.ext)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if cerr := file.Close(); err == nil {
+				err = cerr
+			}
+		}()
+		*f.w = file
+	}
+	return c.WriteShapefile(w, records)
+}
+
+// dbfColumn returns the column of a field of records, numeric when all its
+// values are decimal numbers.
+func dbfColumn(name string, records []Record) dbfField {
+	f := dbfField{name: name, kind: 'N', width: 1}
+	for _, r := range records {
+		v := r.Fields[name]
+		if len(v) > f.width {
+			f.width = len(v)
+		}
+		if v == "" || f.kind == 'C' {
+			continue
+		}
+		if _, err := strconv.ParseFloat(v, 64); err != nil || strings.ContainsAny(v, "eExXnN_") {
+			f.kind = 'C'
+			continue
+		}
+		if i := strings.IndexByte(v, '.'); i >= 0 && len(v)-i-1 > f.decimals {
+			f.decimals = len(v) - i - 1
+		}
+	}
+	if f.kind == 'N' && (f.width > 20 || f.decimals > 15) {
+		f.kind, f.decimals = 'C', 0
+	}
+	if f.kind == 'N' && f.decimals > 0 && f.width < f.decimals+2 {
+		f.width = f.decimals + 2
+	}
+	if f.width > 254 {
+		f.width = 254
+	}
+	return f
+}
+
+// writeDBF writes the attribute table of a shapefile.
+func writeDBF(w io.Writer, fields []dbfField, records []Record, placeKeys []string) error {
+	var b bytes.Buffer
+	now := time.Now()
+	headerLength := 32 + 32*len(fields) + 1
+	recordLength := 1
+	for _, f := range fields {
+		recordLength += f.width
+	}
+	// both lengths are stored on 16 bits
+	if headerLength > math.MaxUint16 || recordLength > math.MaxUint16 {
+		return ErrTooManyFields
+	}
+	b.WriteByte(3)
+	b.Write([]byte{byte(now.Year() - 1900), byte(now.Month()), byte(now.Day())})
+	writeLittleEndian(&b, uint32(len(records)), uint16(headerLength), uint16(recordLength), [20]byte{})
+	for _, f := range fields {
+		var name [11]byte
+		copy(name[:], f.name)
+		writeLittleEndian(&b, name, f.kind, [4]byte{}, uint8(f.width), uint8(f.decimals), [14]byte{})
+	}
+	b.WriteByte(0x0d)
+	for i, r := range records {
+		b.WriteByte(' ')
+		for j, f := range fields {
+			v := r.Fields[f.name]
+			if j == 0 {
+				v = placeKeys[i]
+			}
+			if len(v) > f.width {
+				// cut before the rune crossing the width
+				n := f.width
+				for n > 0 && !utf8.RuneStart(v[n]) {
+					n--
+				}
+				v = v[:n]
+			}
+			pad := strings.Repeat(" ", f.width-len(v))
+			if f.kind == 'N' {
+				b.WriteString(pad + v)
+			} else {
+				b.WriteString(v + pad)
+			}
+		}
+	}
+	b.WriteByte(0x1a)
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+func writeBigEndian(b *bytes.Buffer, values ...interface{}) {
+	for _, v := range values {
+		_ = binary.Write(b, binary.BigEndian, v)
+	}
+}
+
+func writeLittleEndian(b *bytes.Buffer, values ...interface{}) {
+	for _, v := range values {
+		_ = binary.Write(b, binary.LittleEndian, v)
+	}
+}
+
+// ReadShapefile reads the shapes of a shapefile as records, with the fields of
+// its attribute table when dbf is not nil. Each point becomes a record located
+// by its coordinates and PlaceKey, and each polygon a record for each PlaceKey
+// whose center is inside it. Coordinates must be WGS 84 longitudes and
+// latitudes.
+func (c *H3) ReadShapefile(shp, dbf io.Reader) ([]Record, error) {
+	data, err := io.ReadAll(shp)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 100 || binary.BigEndian.Uint32(data) != 9994 {
+		return nil, ErrInvalidShapefile
+	}
+	var table []map[string]string
+	var deleted []bool
+	if dbf != nil {
+		if table, deleted, err = readDBF(dbf); err != nil {
+			return nil, err
+		}
+	}
+	records := []Record{}
+	for i, p := 0, data[100:]; len(p) > 0; i++ {
+		if len(p) < 12 {
+			return nil, ErrInvalidShapefile
+		}
+		length := 2 * int(binary.BigEndian.Uint32(p[4:]))
+		if length < 4 || len(p) < 8+length {
+			return nil, ErrInvalidShapefile
+		}
+		content := p[8 : 8+length]
+		p = p[8+length:]
+		fields := map[string]string{}
+		if table != nil {
+			if i >= len(table) {
+				return nil, ErrInvalidShapefile
+			}
+			if deleted[i] {
+				continue
+			}
+			fields = table[i]
+		}
+		shapes, err := c.readShape(content, fields)
+		if err != nil {
+			return nil, fmt.Errorf("record %d: %w", i+1, err)
+		}
+		records = append(records, shapes...)
+	}
+	return records, nil
+}
+
+// OpenShapefile reads the .shp file of a name, with or without its extension,
+// and its .dbf file when it exists, like ReadShapefile.
+func (c *H3) OpenShapefile(name string) ([]Record, error) {
+	base := strings.TrimSuffix(name, filepath.Ext(name))
+	shp, err := os.Open(base + ".shp")
+	if err != nil {
+		return nil, err
+	}
+	defer shp.Close()
+	dbf, err := os.Open(base + ".dbf")
+	if errors.Is(err, os.ErrNotExist) {
+		return c.ReadShapefile(shp, nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer dbf.Close()
+	return c.ReadShapefile(shp, dbf)
+}
+
+// readShape converts the content of a shapefile record into records.
+func (c *H3) readShape(content []byte, fields map[string]string) ([]Record, error) {
+	le := binary.LittleEndian
+	coord := func(b []byte) (lat, lng float64) {
+		return math.Float64frombits(le.Uint64(b[8:])), math.Float64frombits(le.Uint64(b))
+	}
+	point := func(lat, lng float64) (Record, error) {
+		pk, err := c.FromGeo(lat, lng)
+		if err != nil {
+			return Record{}, err
+		}
+		return Record{PlaceKey: pk, Lat: lat, Lng: lng, HasGeo: true, Fields: copyFields(fields)}, nil
+	}
+	switch shapeType := le.Uint32(content); shapeType {
+	case shapeNull:
+		return nil, nil
+	case shapePoint, shapePointZ, shapePointM:
+		if len(content) < 20 {
+			return nil, ErrInvalidShapefile
+		}
+		r, err := point(coord(content[4:]))
+		if err != nil {
+			return nil, err
+		}
+		return []Record{r}, nil
+	case shapeMultiPoint, shapeMultiPointZ, shapeMultiPointM:
+		if len(content) < 40 {
+			return nil, ErrInvalidShapefile
+		}
+		n := int(le.Uint32(content[36:]))
+		if n < 0 || len(content) < 40+16*n {
+			return nil, ErrInvalidShapefile
+		}
+		records := make([]Record, 0, n)
+		for i := 0; i < n; i++ {
+			r, err := point(coord(content[40+16*i:]))
+			if err != nil {
+				return nil, err
+			}
+			records = append(records, r)
+		}
+		return records, nil
+	case shapePolygon, shapePolygonZ, shapePolygonM:
+		if len(content) < 44 {
+			return nil, ErrInvalidShapefile
+		}
+		numParts, numPoints := int(le.Uint32(content[36:])), int(le.Uint32(content[40:]))
+		points := 44 + 4*numParts
+		if numParts < 0 || numPoints < 0 || len(content) < points+16*numPoints {
+			return nil, ErrInvalidShapefile
+		}
+		rings := make([][]GeoCoord, 0, numParts)
+		for i := 0; i < numParts; i++ {
+			start, end := int(le.Uint32(content[44+4*i:])), numPoints
+			if i+1 < numParts {
+				end = int(le.Uint32(content[48+4*i:]))
+			}
+			if start < 0 || start > end || end > numPoints {
+				return nil, ErrInvalidShapefile
+			}
+			ring := make([]GeoCoord, 0, end-start)
+			for j := start; j < end; j++ {
+				lat, lng := coord(content[points+16*j:])
+				ring = append(ring, GeoCoord{Latitude: lat, Longitude: lng})
+			}
+			if len(ring) > 1 && ring[0] == ring[len(ring)-1] {
+				ring = ring[:len(ring)-1]
+			}
+			if len(ring) < 3 {
+				return nil, ErrInvalidPolygon
+			}
+			rings = append(rings, ring)
+		}
+		cells := map[h3.Index]bool{}
+		for _, polygon := range shapePolygons(rings) {
+			if float64(c.h3.MaxPolyfillSize(polygon, resolution)) > maxCoverCells {
+				return nil, ErrCoverTooLarge
+			}
+			for _, x := range c.h3.Polyfill(polygon, resolution) {
+				cells[x] = true
+			}
+		}
+		xs := make([]h3.Index, 0, len(cells))
+		for x := range cells {
+			xs = append(xs, x)
+		}
+		sort.Slice(xs, func(i, j int) bool { return xs[i] < xs[j] })
+		records := make([]Record, 0, len(xs))
+		for _, x := range xs {
+			records = append(records, Record{PlaceKey: encodeH3Int(uint64(x)), Fields: copyFields(fields)})
+		}
+		return records, nil
+	default:
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedShape, shapeType)
+	}
+}
+
+// shapePolygons groups the rings of a shapefile polygon into polygons: the
+// clockwise rings are outer rings, and the counterclockwise ones holes of the
+// outer ring containing them.
+func shapePolygons(rings [][]GeoCoord) []GeoPolygon {
+	polygons := []GeoPolygon{}
+	outers := [][]point{}
+	holes := [][]GeoCoord{}
+	for _, ring := range rings {
+		pts := loopPoints(ring, ring[0].Longitude)
+		area := 0.0
+		for i := range pts {
+			a, b := pts[i], pts[(i+1)%len(pts)]
+			area += a.x*b.y - b.x*a.y
+		}
+		if area < 0 {
+			polygons = append(polygons, GeoPolygon{Geofence: ring})
+			outers = append(outers, pts)
+		} else {
+			holes = append(holes, ring)
+		}
+	}
+	for _, hole := range holes {
+		for i, outer := range outers {
+			if pointInRing(loopPoints(hole[:1], outer[0].x)[0], outer) {
+				polygons[i].Holes = append(polygons[i].Holes, hole)
+				break
+			}
+		}
+	}
+	return polygons
+}
+
+func copyFields(fields map[string]string) map[string]string {
+	out := make(map[string]string, len(fields))
+	for k, v := range fields {
+		out[k] = v
+	}
+	return out
+}
+
+// readDBF reads the records of a dBASE table, and whether they are deleted.
+func readDBF(r io.Reader) ([]map[string]string, []bool, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(data) < 32 {
+		return nil, nil, ErrInvalidShapefile
+	}
+	le := binary.LittleEndian
+	n := int(le.Uint32(data[4:]))
+	headerLength, recordLength := int(le.Uint16(data[8:])), int(le.Uint16(data[10:]))
+	if headerLength < 33 || headerLength > len(data) || recordLength < 1 {
+		return nil, nil, ErrInvalidShapefile
+	}
+	fields := []dbfField{}
+	width := 1
+	for p := 32; p+32 <= headerLength && data[p] != 0x0d; p += 32 {
+		name := data[p : p+11]
+		if i := bytes.IndexByte(name, 0); i >= 0 {
+			name = name[:i]
+		}
+		f := dbfField{name: string(name), kind: data[p+11], width: int(data[p+16]), decimals: int(data[p+17])}
+		fields = append(fields, f)
+		width += f.width
+	}
+	if width > recordLength || len(data) < headerLength+n*recordLength {
+		return nil, nil, ErrInvalidShapefile
+	}
+	table := make([]map[string]string, n)
+	deleted := make([]bool, n)
+	for i := range table {
+		record := data[headerLength+i*recordLength:]
+		deleted[i] = record[0] == '*'
+		values := make(map[string]string, len(fields))
+		p := 1
+		for _, f := range fields {
+			values[f.name] = strings.TrimSpace(string(record[p : p+f.width]))
+			p += f.width
+		}
+		table[i] = values
+	}
+	return table, deleted, nil
+}
@@ -0,0 +1,240 @@
+package placekey
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestH3_WriteShapefile(t *testing.T) {
+	c := NewH3()
+	defer c.Close()
+	records := []Record{
+		{PlaceKey: "@5vg-7gq-tvz", Fields: map[string]string{"name": "City Hall", "visits": "12", "score": "0.25"}},
+		{PlaceKey: "zzw-222@5vg-7gt-qzz", Fields: map[string]string{"name": "Ferry", "visits": "3", "score": "1.5"}},
+		{Lat: 37.7599, Lng: -122.4148, HasGeo: true, Fields: map[string]string{"name": "Mission", "visits": "", "score": "x"}},
+	}
+	var shp, shx, dbf, prj bytes.Buffer
+	if err := c.WriteShapefile(ShapefileWriters{SHP: &shp, SHX: &shx, DBF: &dbf, PRJ: &prj}, records); err != nil {
+		t.Fatal(err)
+	}
+
+	// headers
+	for _, b := range [][]byte{shp.Bytes(), shx.Bytes()} {
+		if binary.BigEndian.Uint32(b) != 9994 || int(binary.BigEndian.Uint32(b[24:]))*2 != len(b) ||
+			binary.LittleEndian.Uint32(b[28:]) != 1000 || binary.LittleEndian.Uint32(b[32:]) != shapePolygon {
+			t.Errorf("WriteShapefile() got header % x", b[:36])
+		}
+		minX := math.Float64frombits(binary.LittleEndian.Uint64(b[36:]))
+		maxY := math.Float64frombits(binary.LittleEndian.Uint64(b[60:]))
+		if minX > -122.41 || minX < -122.43 || maxY < 37.79 || maxY > 37.81 {
+			t.Errorf("WriteShapefile() got bounding box %v, %v", minX, maxY)
+		}
+	}
+	if shx.Len() != 100+8*len(records) {
+		t.Errorf("WriteShapefile() got a %d bytes index", shx.Len())
+	}
+	for i := range records {
+		offset := 2 * int(binary.BigEndian.Uint32(shx.Bytes()[100+8*i:]))
+		if n := binary.BigEndian.Uint32(shp.Bytes()[offset:]); n != uint32(i+1) {
+			t.Errorf("WriteShapefile() got record %d at the offset of record %d", n, i+1)
+		}
+	}
+	if !strings.HasPrefix(prj.String(), `GEOGCS["GCS_WGS_1984"`) {
+		t.Errorf("WriteShapefile() got projection %s", prj.String())
+	}
+
+	// attributes
+	table, deleted, err := readDBF(bytes.NewReader(dbf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mission, err := c.FromGeo(37.7599, -122.4148)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []map[string]string{
+		{"placekey": "@5vg-7gq-tvz", "name": "City Hall", "visits": "12", "score": "0.25"},
+		{"placekey": "zzw-222@5vg-7gt-qzz", "name": "Ferry", "visits": "3", "score": "1.5"},
+		{"placekey": mission, "name": "Mission", "visits": "", "score": "x"},
+	}
+	if !reflect.DeepEqual(table, want) || !reflect.DeepEqual(deleted, []bool{false, false, false}) {
+		t.Errorf("WriteShapefile() got table %v", table)
+	}
+	b := dbf.Bytes()
+	kinds := map[string]byte{}
+	for p := 32; b[p] != 0x0d; p += 32 {
+		kinds[strings.TrimRight(string(b[p:p+11]), "\x00")] = b[p+11]
+	}
+	if !reflect.DeepEqual(kinds, map[string]byte{"placekey": 'C', "name": 'C', "score": 'C', "visits": 'N'}) {
+		t.Errorf("WriteShapefile() got columns %v", kinds)
+	}
+
+	// round trip
+	got, err := c.ReadShapefile(bytes.NewReader(shp.Bytes()), bytes.NewReader(dbf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(records) {
+		t.Fatalf("ReadShapefile() got %d records", len(got))
+	}
+	for i, r := range got {
+		where := want[i]["placekey"][strings.IndexByte(want[i]["placekey"], '@'):]
+		if r.PlaceKey != where || !reflect.DeepEqual(r.Fields, want[i]) {
+			t.Errorf("ReadShapefile() got %+v, want %s", r, where)
+		}
+	}
+
+	err = c.WriteShapefile(ShapefileWriters{SHP: &shp, SHX: &shx, DBF: &dbf}, []Record{
+		{PlaceKey: "@5vg-7gq-tvz", Fields: map[string]string{"much_too_long": "1"}},
+	})
+	if !errors.Is(err, ErrInvalidFieldName) {
+		t.Errorf("WriteShapefile() error = %v", err)
+	}
+	if err := c.WriteShapefile(ShapefileWriters{SHP: &shp, SHX: &shx, DBF: &dbf}, []Record{{}}); !errors.Is(err, ErrMissingLocation) {
+		t.Errorf("WriteShapefile() error = %v", err)
+	}
+
+	// header and record lengths are limited to 65535 bytes
+	for _, tt := range []struct {
+		fields int
+		value  string
+	}{
+		{fields: 2100, value: "1"},
+		{fields: 300, value: strings.Repeat("x", 254)},
+	} {
+		fields := map[string]string{}
+		for i := 0; i < tt.fields; i++ {
+			fields[fmt.Sprintf("f%d", i)] = tt.value
+		}
+		shp.Reset()
+		err := c.WriteShapefile(ShapefileWriters{SHP: &shp, SHX: &shx, DBF: &dbf}, []Record{{PlaceKey: "@5vg-7gq-tvz", Fields: fields}})
+		if !errors.Is(err, ErrTooManyFields) || shp.Len() != 0 {
+			t.Errorf("WriteShapefile() with %d fields error = %v", tt.fields, err)
+		}
+	}
+
+	// long text is cut at a rune boundary
+	dbf.Reset()
+	long := "a" + strings.Repeat("é", 200)
+	err = c.WriteShapefile(ShapefileWriters{SHP: &shp, SHX: &shx, DBF: &dbf}, []Record{
+		{PlaceKey: "@5vg-7gq-tvz", Fields: map[string]string{"name": long}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	table, _, err = readDBF(bytes.NewReader(dbf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name := table[0]["name"]; name != long[:253] {
+		t.Errorf("WriteShapefile() got name %q", name)
+	}
+}
+
+func TestH3_CreateShapefile(t *testing.T) {
+	c := NewH3()
+	defer c.Close()
+	name := filepath.Join(t.TempDir(), "cells.shp")
+	records := []Record{{PlaceKey: "@5vg-7gq-tvz"}, {PlaceKey: "@5vg-82n-kzz"}}
+	if err := c.CreateShapefile(name, records); err != nil {
+		t.Fatal(err)
+	}
+	for _, ext := range []string{".shp", ".shx", ".dbf", ".prj"} {
+		if _, err := os.Stat(strings.TrimSuffix(name, ".shp") + ext); err != nil {
+			t.Error(err)
+		}
+	}
+	got, err := c.OpenShapefile(strings.TrimSuffix(name, ".shp"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0].PlaceKey != "@5vg-7gq-tvz" || got[1].Fields["placekey"] != "@5vg-82n-kzz" {
+		t.Errorf("OpenShapefile() got = %+v", got)
+	}
+}
+
+// testShapefile returns a shapefile of records of a shape type and contents,
+// with an empty bounding box.
+func testShapefile(shapeType int32, contents ...[]interface{}) []byte {
+	var records bytes.Buffer
+	for i, content := range contents {
+		var b bytes.Buffer
+		writeLittleEndian(&b, content...)
+		writeBigEndian(&records, int32(i+1), int32(b.Len()/2))
+		records.Write(b.Bytes())
+	}
+	var shp bytes.Buffer
+	writeBigEndian(&shp, int32(9994), [5]int32{}, int32(50+records.Len()/2))
+	writeLittleEndian(&shp, int32(1000), shapeType, [8]float64{})
+	shp.Write(records.Bytes())
+	return shp.Bytes()
+}
+
+func TestH3_ReadShapefile(t *testing.T) {
+	c := NewH3()
+	defer c.Close()
+	points := testShapefile(shapePoint,
+		[]interface{}{int32(shapePoint), -122.4193, 37.7793},
+		[]interface{}{int32(shapeNull)},
+		[]interface{}{int32(shapeMultiPoint), [4]float64{}, int32(2), -122.4193, 37.7793, -122.3937, 37.7955},
+	)
+	got, err := c.ReadShapefile(bytes.NewReader(points), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 3 || got[0].PlaceKey != "@5vg-7gq-tvz" || !got[0].HasGeo || got[0].Lat != 37.7793 || got[2].Lng != -122.3937 {
+		t.Errorf("ReadShapefile() got = %+v", got)
+	}
+
+	// a square of about 1 km² with a hole, and a separate triangle
+	square := []interface{}{
+		int32(shapePolygon), [4]float64{}, int32(3), int32(14), int32(0), int32(5), int32(10),
+		-122.42, 37.77, -122.42, 37.78, -122.41, 37.78, -122.41, 37.77, -122.42, 37.77,
+		-122.417, 37.773, -122.413, 37.773, -122.413, 37.777, -122.417, 37.777, -122.417, 37.773,
+		-122.40, 37.79, -122.40, 37.80, -122.39, 37.79, -122.40, 37.79,
+	}
+	got, err = c.ReadShapefile(bytes.NewReader(testShapefile(shapePolygon, square)), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cells := map[string]bool{}
+	for _, r := range got {
+		lat, lng, err := c.ToGeo(r.PlaceKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		inSquare := lat >= 37.77 && lat <= 37.78 && lng >= -122.42 && lng <= -122.41
+		inHole := lat > 37.773 && lat < 37.777 && lng > -122.417 && lng < -122.413
+		inTriangle := lat >= 37.79 && lat <= 37.80 && lng >= -122.40 && lng <= -122.39
+		if !(inSquare && !inHole) && !inTriangle {
+			t.Errorf("ReadShapefile() got %s at (%v, %v)", r.PlaceKey, lat, lng)
+		}
+		cells[r.PlaceKey] = true
+	}
+	// about 1 km² less the hole, plus 0.5 km², with cells of 0.015 km²
+	if len(cells) != len(got) || len(got) < 80 || len(got) > 130 {
+		t.Errorf("ReadShapefile() got %d cells", len(got))
+	}
+
+	for _, tt := range []struct {
+		data []byte
+		err  error
+	}{
+		{[]byte("not a shapefile"), ErrInvalidShapefile},
+		{testShapefile(3, []interface{}{int32(3), [4]float64{}, int32(0), int32(0)}), ErrUnsupportedShape},
+		{testShapefile(shapePoint, []interface{}{int32(shapePoint), 1.0}), ErrInvalidShapefile},
+		{testShapefile(shapePolygon, []interface{}{int32(shapePolygon), [4]float64{}, int32(1), int32(2), int32(0), 0.0, 0.0, 1.0, 1.0}), ErrInvalidPolygon},
+	} {
+		if _, err := c.ReadShapefile(bytes.NewReader(tt.data), nil); !errors.Is(err, tt.err) {
+			t.Errorf("ReadShapefile() error = %v, want %v", err, tt.err)
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package placekey
+
+import (
+	"errors"
+	"math"
+
+	"github.com/diegosz/placekey-go/internal/h3"
+)
+
+// Kernel is the weighting of the neighbors of a cell when smoothing.
+type Kernel int
+
+const (
+	// KernelUniform weighs every cell within K grid steps equally.
+	KernelUniform Kernel = iota
+	// KernelGaussian weighs the cells by exp(-d²/2σ²), d being the grid
+	// distance.
+	KernelGaussian
+)
+
+var ErrInvalidKernel = errors.New("invalid kernel")
+var ErrInvalidBandwidth = errors.New("invalid bandwidth")
+var ErrInvalidPower = errors.New("invalid power")
+
+// SmoothOptions configures Smooth.
+type SmoothOptions struct {
+	Kernel Kernel
+	// K is the number of grid steps of the kernel, 1 when zero.
+	K int
+	// Sigma is the standard deviation of KernelGaussian in grid steps, K/2
+	// when zero.
+	Sigma float64
+	// Fill smooths every cell within K grid steps of a value, instead of the
+	// cells with values only.
+	Fill bool
+}
+
+// Smooth returns the kernel weighted mean of the values within K grid steps of
+// each cell. Values of PlaceKeys sharing a where part are averaged, and the
+// result is keyed by PlaceKeys without what part.
+func (c *H3) Smooth(values map[string]float64, opts SmoothOptions) (map[string]float64, error) {
+	if opts.Kernel < KernelUniform || opts.Kernel > KernelGaussian {
+		return nil, ErrInvalidKernel
+	}
+	if opts.K < 0 {
+		return nil, ErrInvalidK
+	}
+	if opts.K == 0 {
+		opts.K = 1
+	}
+	if opts.Sigma < 0 || math.IsNaN(opts.Sigma) || math.IsInf(opts.Sigma, 0) {
+		return nil, ErrInvalidBandwidth
+	}
+	if opts.Sigma == 0 {
+		opts.Sigma = float64(opts.K) / 2
+	}
+	cells, err := cellMeans(values)
+	if err != nil {
+		return nil, err
+	}
+	weights := make([]float64, opts.K+1)
+	for d := range weights {
+		weights[d] = 1
+		if opts.Kernel == KernelGaussian {
+			weights[d] = math.Exp(-float64(d*d) / (2 * opts.Sigma * opts.Sigma))
+		}
+	}
+	targets := make(map[h3.Index]bool, len(cells))
+	for x := range cells {
+		targets[x] = true
+		if opts.Fill {
+			for _, y := range c.h3.KRing(x, opts.K) {
+				targets[y] = true
+			}
+		}
+	}
+	out := make(map[string]float64, len(targets))
+	for x := range targets {
+		sum, total := 0.0, 0.0
+		visited := map[h3.Index]bool{}
+		for d := 0; d <= opts.K; d++ {
+			for _, y := range c.ring(x, d, visited) {
+				visited[y] = true
+				if v, ok := cells[y]; ok {
+					sum += weights[d] * v
+					total += weights[d]
+				}
+			}
+		}
+		if total > 0 {
+			out[encodeH3Int(uint64(x))] = sum / total
+		}
+	}
+	return out, nil
+}
+
+// cellMeans returns the mean of the values of the PlaceKeys of each cell.
+func cellMeans(values map[string]float64) (map[h3.Index]float64, error) {
+	sums := map[h3.Index]float64{}
+	counts := map[h3.Index]float64{}
+	for pk, v := range values {
+		x, err := ToH3Index(pk)
+		if err != nil {
+			return nil, err
+		}
+		sums[x] += v
+		counts[x]++
+	}
+	for x := range sums {
+		sums[x] /= counts[x]
+	}
+	return sums, nil
+}
+
+// IDWOptions configures InterpolateIDW.
+type IDWOptions struct {
+	// Power is the exponent of the inverse distance, 2 when zero.
+	Power float64
+	// Neighbors is the number of nearest samples used, all of them when zero.
+	Neighbors int
+	// MaxMeters is the largest distance of a sample used, unlimited when zero.
+	MaxMeters float64
+}
+
+// InterpolateIDW interpolates values at target PlaceKeys from sample PlaceKeys
+// by inverse distance weighting, distances being measured in meters between
+// the centers of the PlaceKeys. A target sharing its cell with samples gets
+// their mean, and targets without samples in range are left out.
+func (c *H3) InterpolateIDW(samples map[string]float64, targets []string, opts IDWOptions) (map[string]float64, error) {
+	if opts.Power < 0 || math.IsNaN(opts.Power) || math.IsInf(opts.Power, 0) {
+		return nil, ErrInvalidPower
+	}
+	if opts.Power == 0 {
+		opts.Power = 2
+	}
+	if opts.Neighbors < 0 {
+		return nil, ErrInvalidK
+	}
+	if opts.MaxMeters < 0 || math.IsNaN(opts.MaxMeters) {
+		return nil, ErrInvalidRadius
+	}
+	idx := NewIndex[float64]()
+	all := make([]Item[float64], 0, len(samples))
+	centers := make([][2]float64, 0, len(samples))
+	for pk, v := range samples {
+		if err := idx.Insert(pk, v); err != nil {
+			return nil, err
+		}
+		lat, lng, err := c.ToGeo(pk)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, Item[float64]{PlaceKey: pk, Value: v})
+		centers = append(centers, [2]float64{lat, lng})
+	}
+	out := make(map[string]float64, len(targets))
+	for _, target := range targets {
+		lat, lng, err := c.ToGeo(target)
+		if err != nil {
+			return nil, err
+		}
+		var items []Item[float64]
+		switch {
+		case opts.Neighbors > 0:
+			items, err = idx.KNearest(c, lat, lng, opts.Neighbors)
+		case opts.MaxMeters > 0:
+			items, err = idx.WithinRadius(c, lat, lng, opts.MaxMeters)
+		default:
+			items = all
+			for i, center := range centers {
+				items[i].Distance = geoDistance(lat, lng, center[0], center[1])
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+		var sum, total, exact, exacts float64
+		for _, item := range items {
+			if opts.MaxMeters > 0 && item.Distance > opts.MaxMeters {
+				continue
+			}
+			if item.Distance == 0 {
+				exact += item.Value
+				exacts++
+				continue
+			}
+			w := math.Pow(item.Distance, -opts.Power)
+			sum += w * item.Value
+			total += w
+		}
+		switch {
+		case exacts > 0:
+			out[target] = exact / exacts
+		case total > 0:
+			out[target] = sum / total
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,128 @@
+package placekey
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestH3_Smooth(t *testing.T) {
+	c := NewH3()
+	defer c.Close()
+	// a single spike in a ring of zeros
+	values := map[string]float64{}
+	for _, pk := range testRing(t, c, "@5vg-7gq-tvz", 1) {
+		values[pk] = 0
+	}
+	values["@5vg-7gq-tvz"] = 7
+	tests := []struct {
+		name   string
+		opts   SmoothOptions
+		center float64
+		ring   float64
+		cells  int
+	}{
+		// the center sees the 7 cells, a ring cell 4 of them
+		{"uniform", SmoothOptions{}, 1, 7.0 / 4, 7},
+		{"gaussian", SmoothOptions{Kernel: KernelGaussian, Sigma: 1}, 7 / (1 + 6*math.Exp(-0.5)), 7 * math.Exp(-0.5) / (1 + 3*math.Exp(-0.5)), 7},
+		{"fill", SmoothOptions{Fill: true}, 1, 7.0 / 4, 19},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := c.Smooth(values, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != tt.cells {
+				t.Errorf("Smooth() got %d cells, want %d", len(got), tt.cells)
+			}
+			for pk, v := range got {
+				want := tt.ring
+				if pk == "@5vg-7gq-tvz" {
+					want = tt.center
+				}
+				if _, ok := values[pk]; !ok {
+					// filled cells see a single ring cell or two
+					want = 0
+				}
+				if !almostEqual(v, want) {
+					t.Errorf("Smooth() %s got = %v, want %v", pk, v, want)
+				}
+			}
+		})
+	}
+	if got, _ := c.Smooth(map[string]float64{"@5vg-7gq-tvz": 1, "zzw-222@5vg-7gq-tvz": 3}, SmoothOptions{}); got["@5vg-7gq-tvz"] != 2 {
+		t.Errorf("Smooth() got = %v", got)
+	}
+	for _, tt := range []struct {
+		opts SmoothOptions
+		err  error
+	}{
+		{SmoothOptions{Kernel: 2}, ErrInvalidKernel},
+		{SmoothOptions{K: -1}, ErrInvalidK},
+		{SmoothOptions{Sigma: -1}, ErrInvalidBandwidth},
+	} {
+		if _, err := c.Smooth(values, tt.opts); !errors.Is(err, tt.err) {
+			t.Errorf("Smooth(%+v) error = %v, want %v", tt.opts, err, tt.err)
+		}
+	}
+}
+
+func TestH3_InterpolateIDW(t *testing.T) {
+	c := NewH3()
+	defer c.Close()
+	// City Hall and the Ferry Building, with a target on the line between
+	// them closer to City Hall
+	samples := map[string]float64{"@5vg-7gq-tvz": 10, "@5vg-7gt-qzz": 20}
+	target, err := c.FromGeo(37.7840, -122.4130)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d1, _ := c.Distance(target, "@5vg-7gq-tvz")
+	d2, _ := c.Distance(target, "@5vg-7gt-qzz")
+	w1, w2 := 1/(d1*d1), 1/(d2*d2)
+	targets := []string{target, "@5vg-7gq-tvz", "@nxd-g5g-xyv"}
+
+	got, err := c.InterpolateIDW(samples, targets, IDWOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (10*w1 + 20*w2) / (w1 + w2); !almostEqual(got[target], want) || want > 15 {
+		t.Errorf("InterpolateIDW() got = %v, want %v", got[target], want)
+	}
+	if got["@5vg-7gq-tvz"] != 10 || len(got) != 3 {
+		t.Errorf("InterpolateIDW() got = %v", got)
+	}
+
+	got, err = c.InterpolateIDW(samples, targets, IDWOptions{Neighbors: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got[target] != 10 {
+		t.Errorf("InterpolateIDW() nearest got = %v", got[target])
+	}
+
+	got, err = c.InterpolateIDW(samples, targets, IDWOptions{MaxMeters: 5000, Power: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := got["@nxd-g5g-xyv"]; ok || len(got) != 2 {
+		t.Errorf("InterpolateIDW() within 5 km got = %v", got)
+	}
+	if want := (10/d1 + 20/d2) / (1/d1 + 1/d2); !almostEqual(got[target], want) {
+		t.Errorf("InterpolateIDW() got = %v, want %v", got[target], want)
+	}
+
+	for _, tt := range []struct {
+		opts IDWOptions
+		err  error
+	}{
+		{IDWOptions{Power: -1}, ErrInvalidPower},
+		{IDWOptions{Neighbors: -1}, ErrInvalidK},
+		{IDWOptions{MaxMeters: -1}, ErrInvalidRadius},
+	} {
+		if _, err := c.InterpolateIDW(samples, targets, tt.opts); !errors.Is(err, tt.err) {
+			t.Errorf("InterpolateIDW(%+v) error = %v, want %v", tt.opts, err, tt.err)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package placekey
+
+import (
+	"errors"
+	"math"
+	"sort"
+
+	"github.com/diegosz/placekey-go/internal/h3"
+)
+
+var ErrInsufficientData = errors.New("insufficient data")
+
+// Hotspot is the Getis-Ord Gi* statistic of a PlaceKey: a z-score, positive
+// for clusters of high values and negative for clusters of low values, and its
+// two-sided p-value.
+type Hotspot struct {
+	PlaceKey string
+	Value    float64
+	Z, P     float64
+}
+
+// MoransI is the global Moran's I statistic of spatial autocorrelation, with
+// its expected value, variance, z-score and two-sided p-value under the
+// normality assumption.
+type MoransI struct {
+	I, Expected, Variance float64
+	Z, P                  float64
+}
+
+// spatialWeights are the binary neighbor weights of a set of cells: each cell
+// is a neighbor of the cells within k grid steps of it that are in the set.
+type spatialWeights struct {
+	cells     []h3.Index
+	values    []float64
+	neighbors [][]int
+}
+
+// spatialWeights returns the weights of the cells of a map of values by
+// PlaceKey. Values of PlaceKeys sharing a where part are summed.
+func (c *H3) spatialWeights(values map[string]float64, k int) (spatialWeights, error) {
+	if k < 0 {
+		return spatialWeights{}, ErrInvalidK
+	}
+	if k == 0 {
+		k = 1
+	}
+	sums := map[h3.Index]float64{}
+	for pk, v := range values {
+		x, err := ToH3Index(pk)
+		if err != nil {
+			return spatialWeights{}, err
+		}
+		sums[x] += v
+	}
+	w := spatialWeights{cells: make([]h3.Index, 0, len(sums))}
+	for x := range sums {
+		w.cells = append(w.cells, x)
+	}
+	sort.Slice(w.cells, func(i, j int) bool { return w.cells[i] < w.cells[j] })
+	position := make(map[h3.Index]int, len(w.cells))
+	for i, x := range w.cells {
+		position[x] = i
+		w.values = append(w.values, sums[x])
+	}
+	w.neighbors = make([][]int, len(w.cells))
+	for i, x := range w.cells {
+		for _, n := range c.h3.KRing(x, k) {
+			if j, ok := position[n]; ok && j != i {
+				w.neighbors[i] = append(w.neighbors[i], j)
+			}
+		}
+	}
+	return w, nil
+}
+
+// meanStd returns the mean and the population standard deviation of values.
+func meanStd(values []float64) (float64, float64) {
+	n := float64(len(values))
+	sum, sum2 := 0.0, 0.0
+	for _, v := range values {
+		sum += v
+		sum2 += v * v
+	}
+	mean := sum / n
+	return mean, math.Sqrt(math.Max(0, sum2/n-mean*mean))
+}
+
+// pValue returns the two-sided p-value of a standard normal z-score.
+func pValue(z float64) float64 {
+	return math.Erfc(math.Abs(z) / math.Sqrt2)
+}
+
+// GetisOrd returns the Getis-Ord Gi* statistic of each PlaceKey, sorted by
+// PlaceKey, with binary weights over the PlaceKeys of the map within k grid
+// steps, k being 1 when zero. A cell is its own neighbor. Cells of a constant
+// map have a zero z-score.
+func (c *H3) GetisOrd(values map[string]float64, k int) ([]Hotspot, error) {
+	w, err := c.spatialWeights(values, k)
+	if err != nil {
+		return nil, err
+	}
+	n := float64(len(w.cells))
+	if n < 2 {
+		return nil, ErrInsufficientData
+	}
+	mean, s := meanStd(w.values)
+	out := make([]Hotspot, 0, len(w.cells))
+	for i, x := range w.cells {
+		sum := w.values[i]
+		for _, j := range w.neighbors[i] {
+			sum += w.values[j]
+		}
+		weights := float64(len(w.neighbors[i]) + 1)
+		h := Hotspot{PlaceKey: encodeH3Int(uint64(x)), Value: w.values[i], P: 1}
+		if d := s * math.Sqrt((n*weights-weights*weights)/(n-1)); d > 0 {
+			h.Z = (sum - mean*weights) / d
+			h.P = pValue(h.Z)
+		}
+		out = append(out, h)
+	}
+	return out, nil
+}
+
+// MoransI returns the global Moran's I of the values of a map of PlaceKeys,
+// with binary weights over the PlaceKeys of the map within k grid steps, k
+// being 1 when zero. A cell is not its own neighbor.
+func (c *H3) MoransI(values map[string]float64, k int) (MoransI, error) {
+	w, err := c.spatialWeights(values, k)
+	if err != nil {
+		return MoransI{}, err
+	}
+	n := float64(len(w.cells))
+	if n < 3 {
+		return MoransI{}, ErrInsufficientData
+	}
+	mean, _ := meanStd(w.values)
+	var cross, squares, weights, s2 float64
+	inbound := make([]float64, len(w.cells))
+	pairs := map[[2]int]float64{}
+	for i := range w.cells {
+		zi := w.values[i] - mean
+		squares += zi * zi
+		for _, j := range w.neighbors[i] {
+			cross += zi * (w.values[j] - mean)
+			weights++
+			inbound[j]++
+			pairs[[2]int{i, j}]++
+		}
+	}
+	if weights == 0 || squares == 0 {
+		return MoransI{}, ErrInsufficientData
+	}
+	// s1 sums (wij + wji)² over the ordered pairs, halved
+	s1 := 0.0
+	for p, wij := range pairs {
+		wji := pairs[[2]int{p[1], p[0]}]
+		s1 += (wij + wji) * (wij + wji)
+		if wji == 0 {
+			// the term of the reverse pair, which is not in pairs
+			s1 += wij * wij
+		}
+	}
+	s1 /= 2
+	for i := range w.cells {
+		d := float64(len(w.neighbors[i])) + inbound[i]
+		s2 += d * d
+	}
+	m := MoransI{
+		I:        n / weights * cross / squares,
+		Expected: -1 / (n - 1),
+	}
+	m.Variance = (n*n*s1-n*s2+3*weights*weights)/((n*n-1)*weights*weights) - m.Expected*m.Expected
+	if m.Variance > 0 {
+		m.Z = (m.I - m.Expected) / math.Sqrt(m.Variance)
+	}
+	m.P = pValue(m.Z)
+	return m, nil
+}
@@ -0,0 +1,141 @@
+package placekey
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// testRing returns the PlaceKeys within k grid steps of a PlaceKey.
+func testRing(t *testing.T, c *H3, placeKey string, k int) []string {
+	t.Helper()
+	x, err := ToH3Index(placeKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pks := []string{}
+	for _, n := range c.h3.KRing(x, k) {
+		pks = append(pks, encodeH3Int(uint64(n)))
+	}
+	return pks
+}
+
+func TestH3_GetisOrd(t *testing.T) {
+	c := NewH3()
+	defer c.Close()
+	// a single high value in the middle of a ring: the ring cells see it as
+	// one of their 4 neighbors, and the center as one of its 7
+	values := map[string]float64{}
+	for _, pk := range testRing(t, c, "@5vg-7gq-tvz", 1) {
+		values[pk] = 0
+	}
+	values["@5vg-7gq-tvz"] = 1
+	got, err := c.GetisOrd(values, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 7 {
+		t.Fatalf("GetisOrd() got %d cells", len(got))
+	}
+	for _, h := range got {
+		want := 3 / math.Sqrt(12)
+		if h.PlaceKey == "@5vg-7gq-tvz" {
+			want = 0
+		}
+		if !almostEqual(h.Z, want) || !almostEqual(h.P, math.Erfc(want/math.Sqrt2)) {
+			t.Errorf("GetisOrd() %s got z = %v, p = %v, want z = %v", h.PlaceKey, h.Z, h.P, want)
+		}
+	}
+
+	// a cluster of high values in noise
+	values = map[string]float64{}
+	r := rand.New(rand.NewSource(1))
+	for _, pk := range testRing(t, c, "@5vg-7gq-tvz", 10) {
+		values[pk] = r.Float64()
+	}
+	hotspot, err := c.FromGeo(37.7793, -122.4150)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, pk := range testRing(t, c, hotspot, 2) {
+		if _, ok := values[pk]; !ok {
+			t.Fatalf("%s is not in the test ring", pk)
+		}
+		values[pk] = 10
+	}
+	got, err = c.GetisOrd(values, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	significant := 0
+	for _, h := range got {
+		if h.PlaceKey == hotspot && (h.Z < 3 || h.P > 0.01) {
+			t.Errorf("GetisOrd() hotspot got z = %v, p = %v", h.Z, h.P)
+		}
+		if h.Z > 3 {
+			significant++
+		}
+	}
+	if significant < 7 || significant > 40 {
+		t.Errorf("GetisOrd() got %d significant cells", significant)
+	}
+
+	if _, err := c.GetisOrd(map[string]float64{"@5vg-7gq-tvz": 1}, 1); !errors.Is(err, ErrInsufficientData) {
+		t.Errorf("GetisOrd() error = %v", err)
+	}
+	if _, err := c.GetisOrd(values, -1); !errors.Is(err, ErrInvalidK) {
+		t.Errorf("GetisOrd() error = %v", err)
+	}
+}
+
+func TestH3_MoransI(t *testing.T) {
+	c := NewH3()
+	defer c.Close()
+	values := map[string]float64{}
+	for _, pk := range testRing(t, c, "@5vg-7gq-tvz", 1) {
+		values[pk] = 0
+	}
+	values["@5vg-7gq-tvz"] = 1
+	got, err := c.MoransI(values, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !almostEqual(got.I, -5.0/12) || !almostEqual(got.Expected, -1.0/6) {
+		t.Errorf("MoransI() got = %+v", got)
+	}
+
+	// a smooth gradient is positively autocorrelated, noise is not
+	smooth, noise := map[string]float64{}, map[string]float64{}
+	r := rand.New(rand.NewSource(1))
+	for _, pk := range testRing(t, c, "@5vg-7gq-tvz", 10) {
+		lat, _, err := c.ToGeo(pk)
+		if err != nil {
+			t.Fatal(err)
+		}
+		smooth[pk] = lat
+		noise[pk] = r.Float64()
+	}
+	got, err = c.MoransI(smooth, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.I < 0.8 || got.Z < 10 || got.P > 1e-6 {
+		t.Errorf("MoransI() of a gradient got = %+v", got)
+	}
+	got, err = c.MoransI(noise, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(got.Z) > 3 {
+		t.Errorf("MoransI() of noise got = %+v", got)
+	}
+
+	constant := map[string]float64{}
+	for _, pk := range testRing(t, c, "@5vg-7gq-tvz", 1) {
+		constant[pk] = 1
+	}
+	if _, err := c.MoransI(constant, 1); !errors.Is(err, ErrInsufficientData) {
+		t.Errorf("MoransI() error = %v", err)
+	}
+}
@@ -0,0 +1,375 @@
+package placekey
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/diegosz/placekey-go/internal/h3"
+)
+
+// TileSize is the width and height of the map tiles in pixels.
+const TileSize = 256
+
+// MaxTileZoom is the deepest zoom level of the map tiles.
+const MaxTileZoom = 24
+
+// equatorMeters is the length of the equator of the Web Mercator sphere.
+const equatorMeters = 2 * math.Pi * 6378137
+
+// res0EdgeMeters is the average edge length of the resolution 0 H3 cells, each
+// resolution dividing it by √7.
+const res0EdgeMeters = 1107712.591
+
+var ErrInvalidTile = errors.New("invalid tile")
+
+// TileOptions configures NewTileSet.
+type TileOptions struct {
+	// Value is the value of an aggregate coloring its cell, its count when nil.
+	Value func(Aggregate) float64
+	// Scale colors the values, the DefaultColorScale of the range of the values
+	// at each resolution when nil.
+	Scale *ColorScale
+	// MinCellPixels is the smallest average edge length in pixels of the cells
+	// drawn at a zoom level, the aggregates being rolled up to the finest
+	// resolution whose cells are at least that large, 4 when zero.
+	MinCellPixels float64
+	// Layer is the name of the layer of the vector tiles, "placekeys" when
+	// empty.
+	Layer string
+}
+
+// tileCell is a cell projected with Web Mercator onto the unit square.
+type tileCell struct {
+	x          h3.Index
+	agg        Aggregate
+	value      float64
+	ring       []point
+	minX, maxX float64
+	minY, maxY float64
+}
+
+// tileGrid buckets cells by the tiles of a zoom level they overlap, so that a
+// tile of that zoom level or a deeper one is drawn from the cells of a single
+// bucket.
+type tileGrid struct {
+	zoom    int
+	buckets map[int][]int
+}
+
+// newTileGrid buckets cells by the tiles they overlap at a zoom level, the
+// cells crossing the antimeridian wrapping around.
+func newTileGrid(cells []tileCell, zoom int) tileGrid {
+	g := tileGrid{zoom: zoom, buckets: map[int][]int{}}
+	n := 1 << zoom
+	row := func(y float64) int {
+		return int(math.Max(0, math.Min(float64(n-1), math.Floor(y*float64(n)))))
+	}
+	for i, cell := range cells {
+		x0 := int(math.Floor(cell.minX * float64(n)))
+		x1 := int(math.Floor(cell.maxX * float64(n)))
+		if x1-x0 >= n {
+			x1 = x0 + n - 1
+		}
+		for y := row(cell.minY); y <= row(cell.maxY); y++ {
+			for x := x0; x <= x1; x++ {
+				key := y*n + (x%n+n)%n
+				g.buckets[key] = append(g.buckets[key], i)
+			}
+		}
+	}
+	return g
+}
+
+// positions returns the positions of the cells that may intersect a valid tile
+// at the zoom level of the grid or a deeper one, in increasing order.
+func (g tileGrid) positions(z, x, y int) []int {
+	shift := z - g.zoom
+	return g.buckets[(y>>shift)<<g.zoom+x>>shift]
+}
+
+// TileSet renders the aggregates of an Aggregator as XYZ map tiles, rolled up
+// to a resolution matching each zoom level.
+//
+// The cells of every resolution are projected and bucketed by tile when the
+// TileSet is created, so that drawing a tile only visits the cells around it.
+// The TileSet does not need an H3 context afterwards, and it is safe for
+// concurrent use, notably as an http.Handler.
+type TileSet struct {
+	opts   TileOptions
+	cells  [resolution + 1][]tileCell
+	grids  [resolution + 1]tileGrid
+	scales [resolution + 1]ColorScale
+}
+
+// NewTileSet returns a TileSet of a snapshot of the aggregates of an
+// Aggregator.
+func (c *H3) NewTileSet(a *Aggregator, opts TileOptions) (*TileSet, error) {
+	if opts.MinCellPixels < 0 || math.IsNaN(opts.MinCellPixels) || math.IsInf(opts.MinCellPixels, 0) {
+		return nil, ErrInvalidSize
+	}
+	if opts.MinCellPixels == 0 {
+		opts.MinCellPixels = 4
+	}
+	if opts.Layer == "" {
+		opts.Layer = "placekeys"
+	}
+	if opts.Value == nil {
+		opts.Value = func(agg Aggregate) float64 { return float64(agg.Count) }
+	}
+	t := &TileSet{opts: opts}
+	aggs := a.snapshot()
+	for res := 0; res <= resolution; res++ {
+		parents := map[h3.Index]Aggregate{}
+		for x, agg := range aggs {
+			p := c.h3.ToParent(x, res)
+			parents[p] = parents[p].add(agg)
+		}
+		cells := make([]tileCell, 0, len(parents))
+		values := make(map[string]float64, len(parents))
+		for x, agg := range parents {
+			cell := c.tileCell(x, res)
+			cell.agg, cell.value = agg, opts.Value(agg)
+			cells = append(cells, cell)
+			values[strconv.FormatUint(uint64(x), 16)] = cell.value
+		}
+		sort.Slice(cells, func(i, j int) bool { return cells[i].x < cells[j].x })
+		t.cells[res] = cells
+		// the coarsest zoom level drawing the resolution
+		zoom := 0
+		for zoom < MaxTileZoom && t.Resolution(zoom) < res {
+			zoom++
+		}
+		t.grids[res] = newTileGrid(cells, zoom)
+		if opts.Scale != nil {
+			t.scales[res] = *opts.Scale
+		} else {
+			t.scales[res] = DefaultColorScale(valueRange(values))
+		}
+	}
+	return t, nil
+}
+
+// tileCell returns a cell of a resolution projected with Web Mercator.
+func (c *H3) tileCell(x h3.Index, res int) tileCell {
+	cell := tileCell{x: x}
+	cell.minX, cell.minY = math.Inf(1), math.Inf(1)
+	cell.maxX, cell.maxY = math.Inf(-1), math.Inf(-1)
+	for _, p := range c.tileRing(x, res) {
+		var q point
+		q.x, q.y = mercator(p.y, p.x)
+		cell.ring = append(cell.ring, q)
+		cell.minX, cell.minY = math.Min(cell.minX, q.x), math.Min(cell.minY, q.y)
+		cell.maxX, cell.maxY = math.Max(cell.maxX, q.x), math.Max(cell.maxY, q.y)
+	}
+	return cell
+}
+
+// tileRing returns the boundary of a cell as (longitude, latitude) points
+// unwrapped around its center. The boundary of a cell holding a pole goes once
+// around the globe: it is cut at its longest edge, which may run across the
+// pole, and closed along the edge of the map.
+func (c *H3) tileRing(x h3.Index, res int) []point {
+	center := c.h3.ToGeo(x)
+	boundary := c.h3.ToGeoBoundary(x)
+	pole := 0.0
+	for _, lat := range []float64{90, -90} {
+		if c.h3.FromGeo(GeoCoord{Latitude: lat}, res) == x {
+			pole = lat
+		}
+	}
+	if pole == 0 {
+		return loopPoints(boundary, center.Longitude)
+	}
+	longest, cut := -1.0, 0
+	for i := range boundary {
+		j := (i + 1) % len(boundary)
+		if d := math.Abs(normalizeLng(boundary[j].Longitude - boundary[i].Longitude)); d > longest {
+			longest, cut = d, j
+		}
+	}
+	pts := loopPoints(append(boundary[cut:len(boundary):len(boundary)], boundary[:cut]...), center.Longitude)
+	first, last := pts[0], pts[len(pts)-1]
+	end := first.x + math.Copysign(360, last.x-first.x)
+	return append(pts, point{x: end, y: first.y}, point{x: end, y: pole}, point{x: first.x, y: pole})
+}
+
+// Resolution returns the resolution of the cells drawn at a zoom level.
+func (t *TileSet) Resolution(z int) int {
+	edge := res0EdgeMeters / equatorMeters * TileSize * math.Exp2(float64(z))
+	res := 0
+	for res < resolution && edge/math.Sqrt(7) >= t.opts.MinCellPixels {
+		edge /= math.Sqrt(7)
+		res++
+	}
+	return res
+}
+
+// tileCells calls fn with the cells intersecting a tile, and the offset of
+// their copy of the world, -1, 0 or 1, cells crossing the antimeridian being
+// seen from both sides.
+func (t *TileSet) tileCells(z, x, y int, fn func(cell *tileCell, offset float64)) error {
+	if err := checkTile(z, x, y); err != nil {
+		return err
+	}
+	res := t.Resolution(z)
+	cells := t.cells[res]
+	visitTileCells(cells, t.grids[res].positions(z, x, y), z, x, y, func(i int, offset float64) { fn(&cells[i], offset) })
+	return nil
+}
+
+// checkTile returns ErrInvalidTile when a tile is not on the map.
+func checkTile(z, x, y int) error {
+	if z < 0 || z > MaxTileZoom {
+		return ErrInvalidTile
+	}
+	n := 1 << z
+	if x < 0 || x >= n || y < 0 || y >= n {
+		return ErrInvalidTile
+	}
+	return nil
+}
+
+// visitTileCells calls fn with the positions, among some positions, of the
+// cells intersecting a valid tile, like TileSet.tileCells.
+func visitTileCells(cells []tileCell, positions []int, z, x, y int, fn func(i int, offset float64)) {
+	size := 1 / math.Exp2(float64(z))
+	minX, minY := float64(x)*size, float64(y)*size
+	maxX, maxY := minX+size, minY+size
+	for _, i := range positions {
+		cell := &cells[i]
+		if cell.maxY < minY || cell.minY > maxY {
+			continue
+		}
+		for _, offset := range []float64{-1, 0, 1} {
+			if cell.maxX+offset >= minX && cell.minX+offset <= maxX {
+				fn(i, offset)
+			}
+		}
+	}
+}
+
+// RenderPNG draws the tile at zoom z, column x and row y, with transparent
+// pixels outside the cells.
+func (t *TileSet) RenderPNG(z, x, y int) (*image.NRGBA, error) {
+	img := image.NewNRGBA(image.Rect(0, 0, TileSize, TileSize))
+	scale := t.scales[t.Resolution(z)]
+	pixels := TileSize * math.Exp2(float64(z))
+	err := t.tileCells(z, x, y, func(cell *tileCell, offset float64) {
+		ring := make([]point, len(cell.ring))
+		for i, p := range cell.ring {
+			ring[i] = point{
+				x: (p.x+offset)*pixels - float64(x*TileSize),
+				y: p.y*pixels - float64(y*TileSize),
+			}
+		}
+		fillRing(img, ring, color.NRGBAModel.Convert(scale.Color(cell.value)).(color.NRGBA))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return img, nil
+}
+
+// fillRing fills the pixels of an image whose centers are inside a ring, using
+// the even odd rule.
+func fillRing(img *image.NRGBA, ring []point, c color.NRGBA) {
+	b := img.Bounds()
+	minY, maxY := math.Inf(1), math.Inf(-1)
+	for _, p := range ring {
+		minY, maxY = math.Min(minY, p.y), math.Max(maxY, p.y)
+	}
+	y0 := int(math.Max(float64(b.Min.Y), math.Floor(minY)))
+	y1 := int(math.Min(float64(b.Max.Y-1), math.Ceil(maxY)))
+	xs := []float64{}
+	for py := y0; py <= y1; py++ {
+		cy := float64(py) + 0.5
+		xs = xs[:0]
+		for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+			a, b := ring[i], ring[j]
+			if (a.y > cy) != (b.y > cy) {
+				xs = append(xs, a.x+(cy-a.y)*(b.x-a.x)/(b.y-a.y))
+			}
+		}
+		sort.Float64s(xs)
+		for i := 0; i+1 < len(xs); i += 2 {
+			// pixels whose centers are within [xs[i], xs[i+1])
+			x0 := int(math.Max(float64(b.Min.X), math.Ceil(xs[i]-0.5)))
+			x1 := int(math.Min(float64(b.Max.X), math.Ceil(xs[i+1]-0.5)))
+			for px := x0; px < x1; px++ {
+				img.SetNRGBA(px, py, c)
+			}
+		}
+	}
+}
+
+// ServeHTTP serves the tiles as PNG images and Mapbox Vector Tiles at paths
+// ending with /{z}/{x}/{y}.png and /{z}/{x}/{y}.mvt, such as
+// /tiles/{z}/{x}/{y}.png. The .pbf extension is an alias of .mvt.
+func (t *TileSet) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	z, x, y, ext, ok := parseTilePath(r.URL.Path)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	var body []byte
+	var contentType string
+	switch ext {
+	case ".png":
+		img, err := t.RenderPNG(z, x, y)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, img); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		body, contentType = buf.Bytes(), "image/png"
+	case ".mvt", ".pbf":
+		b, err := t.EncodeMVT(z, x, y)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		body, contentType = b, MVTContentType
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	_, _ = w.Write(body)
+}
+
+// parseTilePath parses the zoom, column, row and extension of a path ending
+// with /{z}/{x}/{y}.{ext}.
+func parseTilePath(path string) (z, x, y int, ext string, ok bool) {
+	parts := strings.Split(path, "/")
+	if len(parts) < 4 {
+		return 0, 0, 0, "", false
+	}
+	parts = parts[len(parts)-3:]
+	i := strings.LastIndexByte(parts[2], '.')
+	if i < 0 {
+		return 0, 0, 0, "", false
+	}
+	parts[2], ext = parts[2][:i], parts[2][i:]
+	var xyz [3]int
+	for i, s := range parts {
+		v, err := strconv.Atoi(s)
+		if err != nil {
+			return 0, 0, 0, "", false
+		}
+		xyz[i] = v
+	}
+	return xyz[0], xyz[1], xyz[2], ext, true
+}
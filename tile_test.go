@@ -0,0 +1,258 @@
+package placekey
+
+import (
+	"errors"
+	"image/color"
+	"image/png"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+// testTile returns the tile at zoom z holding a coordinate, and the pixel of
+// the coordinate in it.
+func testTile(z int, lat, lng float64) (x, y, px, py int) {
+	mx, my := mercator(lat, lng)
+	pixels := TileSize * math.Exp2(float64(z))
+	gx, gy := int(mx*pixels), int(my*pixels)
+	return gx / TileSize, gy / TileSize, gx % TileSize, gy % TileSize
+}
+
+func TestParseTilePath(t *testing.T) {
+	tests := []struct {
+		path    string
+		z, x, y int
+		ext     string
+		ok      bool
+	}{
+		{"/tiles/3/2/1.png", 3, 2, 1, ".png", true},
+		{"/api/tiles/15/5241/12663.mvt", 15, 5241, 12663, ".mvt", true},
+		{"/tiles/3/2/1", 0, 0, 0, "", false},
+		{"/tiles/a/2/1.png", 0, 0, 0, "", false},
+		{"/2/1.png", 0, 0, 0, "", false},
+	}
+	for _, tt := range tests {
+		z, x, y, ext, ok := parseTilePath(tt.path)
+		if z != tt.z || x != tt.x || y != tt.y || ext != tt.ext || ok != tt.ok {
+			t.Errorf("parseTilePath(%q) got = %v, %v, %v, %q, %v", tt.path, z, x, y, ext, ok)
+		}
+	}
+}
+
+func TestTileSet_Resolution(t *testing.T) {
+	c := NewH3()
+	defer c.Close()
+	ts, err := c.NewTileSet(NewAggregator(), TileOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous := 0
+	for z := 0; z <= MaxTileZoom; z++ {
+		res := ts.Resolution(z)
+		if res < previous || res > 10 {
+			t.Errorf("Resolution(%d) got = %v", z, res)
+		}
+		previous = res
+	}
+	if ts.Resolution(0) != 0 || ts.Resolution(14) != 10 || ts.Resolution(13) != 9 {
+		t.Errorf("Resolution() got = %v, %v, %v", ts.Resolution(0), ts.Resolution(13), ts.Resolution(14))
+	}
+	if _, err := c.NewTileSet(NewAggregator(), TileOptions{MinCellPixels: -1}); !errors.Is(err, ErrInvalidSize) {
+		t.Errorf("NewTileSet() error = %v", err)
+	}
+}
+
+func TestTileSet_RenderPNG(t *testing.T) {
+	c := NewH3()
+	defer c.Close()
+	a := NewAggregator()
+	for _, pk := range []string{"@5vg-7gq-tvz", "@5vg-7gq-tvz", "@5vg-7gt-qzz"} {
+		if err := a.Add(pk, 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ts, err := c.NewTileSet(a, TileOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	transparent := color.NRGBA{}
+	for z := 0; z <= 18; z++ {
+		lat, lng, err := c.ToGeo("@5vg-7gq-tvz")
+		if err != nil {
+			t.Fatal(err)
+		}
+		x, y, px, py := testTile(z, lat, lng)
+		img, err := ts.RenderPNG(z, x, y)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := img.NRGBAAt(px, py); got == transparent {
+			t.Errorf("RenderPNG(%d, %d, %d) got no color at (%d, %d)", z, x, y, px, py)
+		}
+		if z >= 14 {
+			// the busiest cell has the darkest color
+			want := color.NRGBAModel.Convert(DefaultColorScale(1, 2).To)
+			if got := img.NRGBAAt(px, py); got != want {
+				t.Errorf("RenderPNG(%d, %d, %d) got = %v, want %v", z, x, y, got, want)
+			}
+		}
+		if z < 8 {
+			continue
+		}
+		empty, err := ts.RenderPNG(z, x^1, y)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if empty.NRGBAAt(TileSize/2, TileSize/2) != transparent {
+			t.Errorf("RenderPNG(%d, %d, %d) got a color away from the data", z, x^1, y)
+		}
+	}
+	for _, tile := range [][3]int{{-1, 0, 0}, {0, 1, 0}, {2, 0, 4}, {MaxTileZoom + 1, 0, 0}} {
+		if _, err := ts.RenderPNG(tile[0], tile[1], tile[2]); !errors.Is(err, ErrInvalidTile) {
+			t.Errorf("RenderPNG(%v) error = %v", tile, err)
+		}
+	}
+}
+
+func TestTileSet_RenderPNG_Wrapping(t *testing.T) {
+	c := NewH3()
+	defer c.Close()
+	a := NewAggregator()
+	// the center of the resolution 0 cell holding the north pole
+	pole := c.h3.ToGeo(c.h3.FromGeo(GeoCoord{Latitude: 90}, 0))
+	for _, geo := range [][2]float64{{pole.Latitude, pole.Longitude}, {0, 179.9999}} {
+		pk, err := c.FromGeo(geo[0], geo[1])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := a.Add(pk, 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ts, err := c.NewTileSet(a, TileOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := ts.RenderPNG(0, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	transparent := color.NRGBA{}
+	for _, p := range [][2]int{{0, 0}, {TileSize / 2, 0}, {TileSize - 1, 0}, {0, TileSize / 2}, {TileSize - 1, TileSize / 2}} {
+		if img.NRGBAAt(p[0], p[1]) == transparent {
+			t.Errorf("RenderPNG(0, 0, 0) got no color at %v", p)
+		}
+	}
+	if img.NRGBAAt(TileSize/2, TileSize-1) != transparent {
+		t.Error("RenderPNG(0, 0, 0) got a color at the south pole")
+	}
+}
+
+func TestTileSet_tileCells(t *testing.T) {
+	c := NewH3()
+	defer c.Close()
+	a := NewAggregator()
+	placeKeys := testPlaceKeys(t, c, 300)
+	pole := c.h3.ToGeo(c.h3.FromGeo(GeoCoord{Latitude: 90}, 0))
+	for _, geo := range [][2]float64{{pole.Latitude, pole.Longitude}, {0, 179.9999}, {0, -179.9999}} {
+		pk, err := c.FromGeo(geo[0], geo[1])
+		if err != nil {
+			t.Fatal(err)
+		}
+		placeKeys = append(placeKeys, pk)
+	}
+	for _, pk := range placeKeys {
+		if err := a.Add(pk, 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ts, err := c.NewTileSet(a, TileOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	type visit struct {
+		x      uint64
+		offset float64
+	}
+	for z := 0; z <= 18; z++ {
+		// the tiles around each cell, and the tiles of the corners of the map
+		tiles := map[[2]int]bool{}
+		n := 1 << z
+		for _, xy := range [][2]int{{0, 0}, {n - 1, 0}, {0, n / 2}, {n - 1, n / 2}} {
+			tiles[xy] = true
+		}
+		for _, pk := range placeKeys[:50] {
+			lat, lng, err := c.ToGeo(pk)
+			if err != nil {
+				t.Fatal(err)
+			}
+			x, y, _, _ := testTile(z, lat, lng)
+			for _, d := range [][2]int{{0, 0}, {-1, 0}, {1, 0}, {0, -1}, {0, 1}} {
+				if tx, ty := x+d[0], y+d[1]; tx >= 0 && tx < n && ty >= 0 && ty < n {
+					tiles[[2]int{tx, ty}] = true
+				}
+			}
+		}
+		cells := ts.cells[ts.Resolution(z)]
+		all := make([]int, len(cells))
+		for i := range all {
+			all[i] = i
+		}
+		for xy := range tiles {
+			got, want := []visit{}, []visit{}
+			if err := ts.tileCells(z, xy[0], xy[1], func(cell *tileCell, offset float64) {
+				got = append(got, visit{uint64(cell.x), offset})
+			}); err != nil {
+				t.Fatal(err)
+			}
+			visitTileCells(cells, all, z, xy[0], xy[1], func(i int, offset float64) {
+				want = append(want, visit{uint64(cells[i].x), offset})
+			})
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("tileCells(%d, %d, %d) got = %v, want %v", z, xy[0], xy[1], got, want)
+			}
+		}
+	}
+}
+
+func TestTileSet_ServeHTTP(t *testing.T) {
+	c := NewH3()
+	defer c.Close()
+	a := NewAggregator()
+	if err := a.Add("@5vg-7gq-tvz", 1); err != nil {
+		t.Fatal(err)
+	}
+	ts, err := c.NewTileSet(a, TileOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(http.StripPrefix("/tiles", ts))
+	defer srv.Close()
+	res, err := http.Get(srv.URL + "/tiles/10/163/395.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK || res.Header.Get("Content-Type") != "image/png" {
+		t.Fatalf("GET got status %v, content type %q", res.StatusCode, res.Header.Get("Content-Type"))
+	}
+	img, err := png.Decode(res.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.Bounds().Dx() != TileSize || img.Bounds().Dy() != TileSize {
+		t.Errorf("GET got a %v image", img.Bounds())
+	}
+	for _, path := range []string{"/tiles/10/163/395", "/tiles/10/163/395.jpg", "/tiles/30/0/0.png", "/tiles/x.png"} {
+		res, err := http.Get(srv.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		if res.StatusCode != http.StatusNotFound {
+			t.Errorf("GET %s got status %v", path, res.StatusCode)
+		}
+	}
+}
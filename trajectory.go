@@ -0,0 +1,126 @@
+package placekey
+
+import (
+	"sort"
+	"time"
+)
+
+// DefaultMaxFillSteps is the largest grid distance of a gap filled when
+// TrajectoryOptions.MaxFillSteps is zero, about 100 km.
+const DefaultMaxFillSteps = 1000
+
+// Ping is a timestamped position of a device.
+type Ping struct {
+	Time     time.Time
+	Lat, Lng float64
+}
+
+// Visit is a stay of a device in a PlaceKey, from the first to the last ping in
+// it. Interpolated visits fill the gap between two non-adjacent PlaceKeys and
+// have no pings.
+type Visit struct {
+	PlaceKey     string
+	Enter, Exit  time.Time
+	Pings        int
+	Interpolated bool
+}
+
+// Dwell returns the time spent in the visit.
+func (v Visit) Dwell() time.Duration {
+	return v.Exit.Sub(v.Enter)
+}
+
+// TrajectoryOptions configures how a trajectory is built.
+type TrajectoryOptions struct {
+	// FillGaps inserts interpolated visits along the grid path between
+	// consecutive PlaceKeys that are not adjacent.
+	FillGaps bool
+	// MaxFillSteps is the largest grid distance of a gap to fill,
+	// DefaultMaxFillSteps when zero and unlimited when negative. Larger gaps,
+	// and gaps the grid path cannot cross, are left as is.
+	MaxFillSteps int
+}
+
+// Trajectory is the sequence of PlaceKeys visited by a device.
+type Trajectory struct {
+	Visits []Visit
+}
+
+// BuildTrajectory maps pings to PlaceKeys, in time order, collapsing
+// consecutive pings in the same PlaceKey into a visit.
+func (c *H3) BuildTrajectory(pings []Ping, opts TrajectoryOptions) (Trajectory, error) {
+	sorted := append([]Ping(nil), pings...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Time.Before(sorted[j].Time)
+	})
+	maxSteps := opts.MaxFillSteps
+	if maxSteps == 0 {
+		maxSteps = DefaultMaxFillSteps
+	}
+	visits := []Visit{}
+	for _, p := range sorted {
+		pk, err := c.FromGeo(p.Lat, p.Lng)
+		if err != nil {
+			return Trajectory{}, err
+		}
+		if n := len(visits); n > 0 && visits[n-1].PlaceKey == pk {
+			visits[n-1].Exit = p.Time
+			visits[n-1].Pings++
+			continue
+		}
+		if n := len(visits); n > 0 && opts.FillGaps {
+			visits = append(visits, c.fillGap(visits[n-1], pk, p.Time, maxSteps)...)
+		}
+		visits = append(visits, Visit{PlaceKey: pk, Enter: p.Time, Exit: p.Time, Pings: 1})
+	}
+	return Trajectory{Visits: visits}, nil
+}
+
+// fillGap returns the interpolated visits between a visit and the next
+// PlaceKey entered at a given time, splitting the time between them evenly.
+func (c *H3) fillGap(from Visit, to string, enter time.Time, maxSteps int) []Visit {
+	// the distance is cheap to compute, check it before building the path
+	steps, err := c.GridDistance(from.PlaceKey, to)
+	if err != nil || steps <= 1 || (maxSteps > 0 && steps > maxSteps) {
+		return nil
+	}
+	path, err := c.GridPath(from.PlaceKey, to)
+	if err != nil || len(path) != steps+1 {
+		return nil
+	}
+	gap := enter.Sub(from.Exit)
+	slice := gap / time.Duration(steps)
+	visits := make([]Visit, 0, steps-1)
+	for i := 1; i < steps; i++ {
+		// slice*i does not overflow as it is at most gap, unlike gap*i
+		mid := from.Exit.Add(slice * time.Duration(i))
+		visits = append(visits, Visit{
+			PlaceKey:     path[i],
+			Enter:        mid.Add(-slice / 2),
+			Exit:         mid.Add(slice / 2),
+			Interpolated: true,
+		})
+	}
+	return visits
+}
+
+// PlaceKeys returns the sequence of PlaceKeys visited.
+func (t Trajectory) PlaceKeys() []string {
+	pks := make([]string, 0, len(t.Visits))
+	for _, v := range t.Visits {
+		pks = append(pks, v.PlaceKey)
+	}
+	return pks
+}
+
+// Stays returns the visits, interpolated ones excluded, lasting at least a
+// minimum dwell time.
+func (t Trajectory) Stays(min time.Duration) []Visit {
+	stays := []Visit{}
+	for _, v := range t.Visits {
+		if !v.Interpolated && v.Dwell() >= min {
+			stays = append(stays, v)
+		}
+	}
+	return stays
+}
@@ -0,0 +1,129 @@
+package placekey
+
+import (
+	"testing"
+	"time"
+)
+
+func TestH3_BuildTrajectory(t *testing.T) {
+	t0 := time.Date(2022, 8, 1, 9, 0, 0, 0, time.UTC)
+	pings := []Ping{
+		{Time: t0.Add(20 * time.Minute), Lat: 37.795424, Lng: -122.393715},
+		{Time: t0, Lat: 37.779274, Lng: -122.419262},
+		{Time: t0.Add(5 * time.Minute), Lat: 37.7793, Lng: -122.4192},
+		{Time: t0.Add(15 * time.Minute), Lat: 37.7793, Lng: -122.4192},
+		{Time: t0.Add(30 * time.Minute), Lat: 37.795424, Lng: -122.393715},
+	}
+	tests := []struct {
+		name         string
+		opts         TrajectoryOptions
+		wantVisits   int
+		wantFirst    string
+		wantLast     string
+		wantStays    int
+		wantComplete bool
+	}{
+		{
+			name:       "collapsed",
+			opts:       TrajectoryOptions{},
+			wantVisits: 2,
+			wantFirst:  "@5vg-7gq-tvz",
+			wantLast:   "@5vg-7gt-qzz",
+			wantStays:  2,
+		},
+		{
+			name:         "gaps filled",
+			opts:         TrajectoryOptions{FillGaps: true},
+			wantVisits:   25,
+			wantFirst:    "@5vg-7gq-tvz",
+			wantLast:     "@5vg-7gt-qzz",
+			wantStays:    2,
+			wantComplete: true,
+		},
+		{
+			name:       "gap too large",
+			opts:       TrajectoryOptions{FillGaps: true, MaxFillSteps: 10},
+			wantVisits: 2,
+			wantFirst:  "@5vg-7gq-tvz",
+			wantLast:   "@5vg-7gt-qzz",
+			wantStays:  2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewH3()
+			defer c.Close()
+			got, err := c.BuildTrajectory(pings, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			pks := got.PlaceKeys()
+			if len(pks) != tt.wantVisits {
+				t.Fatalf("BuildTrajectory() got %d visits, want %d", len(pks), tt.wantVisits)
+			}
+			if pks[0] != tt.wantFirst || pks[len(pks)-1] != tt.wantLast {
+				t.Errorf("BuildTrajectory() got %v", pks)
+			}
+			first := got.Visits[0]
+			if first.Pings != 3 || first.Dwell() != 15*time.Minute {
+				t.Errorf("BuildTrajectory() first visit = %+v", first)
+			}
+			if stays := got.Stays(11 * time.Minute); len(stays) != tt.wantStays-1 {
+				t.Errorf("Stays() got %d stays", len(stays))
+			}
+			if stays := got.Stays(0); len(stays) != tt.wantStays {
+				t.Errorf("Stays() got %d stays", len(stays))
+			}
+			if tt.wantComplete {
+				for i := 1; i < len(got.Visits); i++ {
+					d, err := c.GridDistance(got.Visits[i-1].PlaceKey, got.Visits[i].PlaceKey)
+					if err != nil {
+						t.Fatal(err)
+					}
+					if d != 1 {
+						t.Errorf("BuildTrajectory() visits %d and %d not adjacent", i-1, i)
+					}
+					if got.Visits[i].Enter.Before(got.Visits[i-1].Exit) {
+						t.Errorf("BuildTrajectory() visit %d enters before %d exits", i, i-1)
+					}
+				}
+			}
+		})
+	}
+	c := NewH3()
+	defer c.Close()
+	if _, err := c.BuildTrajectory([]Ping{{Lat: 91}}, TrajectoryOptions{}); err == nil {
+		t.Error("BuildTrajectory() expected error")
+	}
+}
+
+func TestH3_BuildTrajectory_LongGap(t *testing.T) {
+	c := NewH3()
+	defer c.Close()
+	// a year and about 2000 cells apart
+	t0 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	pings := []Ping{
+		{Time: t0, Lat: 37.7793, Lng: -122.4192},
+		{Time: t0.AddDate(1, 0, 0), Lat: 37.7793, Lng: -119.8},
+	}
+	got, err := c.BuildTrajectory(pings, TrajectoryOptions{FillGaps: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Visits) != 2 {
+		t.Errorf("BuildTrajectory() got %d visits over the default limit", len(got.Visits))
+	}
+	got, err = c.BuildTrajectory(pings, TrajectoryOptions{FillGaps: true, MaxFillSteps: -1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Visits) < 1500 {
+		t.Fatalf("BuildTrajectory() got %d visits", len(got.Visits))
+	}
+	for i := 1; i < len(got.Visits); i++ {
+		prev, v := got.Visits[i-1], got.Visits[i]
+		if v.Enter.Before(prev.Exit) || v.Exit.Before(v.Enter) || v.Enter.Before(t0) || v.Exit.After(pings[1].Time) {
+			t.Fatalf("BuildTrajectory() got visit %d from %v to %v after %v", i, v.Enter, v.Exit, prev.Exit)
+		}
+	}
+}